@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/conductorone/baton-terraform-cloud/pkg/connector"
 	"github.com/spf13/viper"
 )
 
@@ -9,7 +13,19 @@ var (
 	TokenField = field.StringField(
 		"token",
 		field.WithDescription("The API token used to authenticate with terraform cloud."),
-		field.WithRequired(true),
+		field.WithRequired(false),
+	)
+
+	TokenFile = field.StringField(
+		"token-file",
+		field.WithDescription("Read the API token from this file instead of --token. The file is read again when it changes, so the token can be rotated without a restart"),
+		field.WithRequired(false),
+	)
+
+	TerraformCredentialsFile = field.StringField(
+		"terraform-credentials-file",
+		field.WithDescription("Read the API token for the host of --address from this Terraform CLI credentials file, usually ~/.terraform.d/credentials.tfrc.json as written by terraform login. The file is read again when it changes"),
+		field.WithRequired(false),
 	)
 
 	Address = field.StringField(
@@ -18,19 +34,133 @@ var (
 		field.WithRequired(false),
 		field.WithDefaultValue("https://app.terraform.io"),
 	)
+
+	CABundle = field.StringField(
+		"ca-bundle",
+		field.WithDescription("A PEM file of CA certificates to trust in addition to the system ones, for Terraform Enterprise behind an internal CA"),
+		field.WithRequired(false),
+	)
+
+	ClientCert = field.StringField(
+		"client-cert",
+		field.WithDescription("A PEM client certificate presented to Terraform Enterprise for mutual TLS, requires --client-key"),
+		field.WithRequired(false),
+	)
+
+	ClientKey = field.StringField(
+		"client-key",
+		field.WithDescription("The PEM private key of --client-cert"),
+		field.WithRequired(false),
+	)
+
+	HTTPSProxy = field.StringField(
+		"https-proxy",
+		field.WithDescription("The URL of the proxy used to reach the instances. Default: the HTTPS_PROXY environment variable"),
+		field.WithRequired(false),
+	)
+
+	InsecureSkipVerify = field.BoolField(
+		"insecure-skip-verify",
+		field.WithDescription("Do not verify the server certificate. Only for testing, the API token can be intercepted"),
+		field.WithRequired(false),
+	)
+
+	HTTPRecordFile = field.StringField(
+		"http-record-file",
+		field.WithDescription("Record every API request and response to this file for debugging, as HAR when the name ends with .har and JSON lines otherwise. Authorization headers, tokens and sensitive variable values are redacted"),
+		field.WithRequired(false),
+	)
+
+	HTTPRecordRedactEmails = field.BoolField(
+		"http-record-redact-emails",
+		field.WithDescription("Replace email addresses in --http-record-file with pseudonyms, the same address always gets the same pseudonym"),
+		field.WithRequired(false),
+	)
+
+	HTTPReplayFile = field.StringField(
+		"http-replay-file",
+		field.WithDescription("Serve the responses recorded with --http-record-file instead of calling the instances, to reproduce a sync offline. No token is needed"),
+		field.WithRequired(false),
+	)
+
+	InvitationExpiryDays = field.IntField(
+		"invitation-expiry-days",
+		field.WithDescription("How many days an organization invitation stays valid before pending users are reported as \"invitation expired\". The API does not expose the invitation lifetime of an instance, 0 never reports invitations as expired"),
+		field.WithRequired(false),
+		field.WithDefaultValue(7),
+	)
+
+	Instances = field.StringField(
+		"instances",
+		field.WithDescription("Sync several instances instead of --token and --address, as a JSON list of objects with name, address, token, token_file, terraform_credentials_file, organization_allowlist and organization_denylist. Resource IDs are prefixed with the instance name"),
+		field.WithRequired(false),
+	)
+
+	OrganizationAllowlist = field.StringSliceField(
+		"organization-allowlist",
+		field.WithDescription("Only sync and provision these organizations. Glob patterns such as \"acme-*\" are supported. Default: all organizations"),
+		field.WithRequired(false),
+	)
+
+	OrganizationDenylist = field.StringSliceField(
+		"organization-denylist",
+		field.WithDescription("Never sync or provision these organizations. Glob patterns are supported and take precedence over the allowlist"),
+		field.WithRequired(false),
+	)
+
+	Projects = field.StringSliceField(
+		"projects",
+		field.WithDescription("Only sync these projects and their workspaces, by project name. Default: all projects"),
+		field.WithRequired(false),
+	)
+
+	WorkspaceTags = field.StringSliceField(
+		"workspace-tags",
+		field.WithDescription("Only sync workspaces that have all of these tags, as tag names or key:value tag bindings"),
+		field.WithRequired(false),
+	)
+
+	WorkspaceExcludeTags = field.StringSliceField(
+		"workspace-exclude-tags",
+		field.WithDescription("Do not sync workspaces that have any of these tags, as tag names or key:value tag bindings"),
+		field.WithRequired(false),
+	)
+
 	// ConfigurationFields defines the external configuration required for the
 	// connector to run. Note: these fields can be marked as optional or
 	// required.
 	ConfigurationFields = []field.SchemaField{
 		TokenField,
+		TokenFile,
+		TerraformCredentialsFile,
 		Address,
+		CABundle,
+		ClientCert,
+		ClientKey,
+		HTTPSProxy,
+		InsecureSkipVerify,
+		HTTPRecordFile,
+		HTTPRecordRedactEmails,
+		HTTPReplayFile,
+		InvitationExpiryDays,
+		Instances,
+		OrganizationAllowlist,
+		OrganizationDenylist,
+		Projects,
+		WorkspaceTags,
+		WorkspaceExcludeTags,
 	}
 
 	// FieldRelationships defines relationships between the fields listed in
 	// ConfigurationFields that can be automatically validated. For example, a
 	// username and password can be required together, or an access token can be
 	// marked as mutually exclusive from the username password pair.
-	FieldRelationships = []field.SchemaFieldRelationship{}
+	FieldRelationships = []field.SchemaFieldRelationship{
+		field.FieldsAtLeastOneUsed(TokenField, TokenFile, TerraformCredentialsFile, Instances, HTTPReplayFile),
+		field.FieldsMutuallyExclusive(TokenField, TokenFile, TerraformCredentialsFile, Instances),
+		field.FieldsRequiredTogether(ClientCert, ClientKey),
+		field.FieldsMutuallyExclusive(HTTPRecordFile, HTTPReplayFile),
+	}
 )
 
 // ValidateConfig is run after the configuration is loaded, and should return an
@@ -38,5 +168,72 @@ var (
 // needs to perform extra validations that cannot be encoded with configuration
 // parameters.
 func ValidateConfig(v *viper.Viper) error {
+	if raw := v.GetString(Instances.FieldName); raw != "" {
+		if _, err := connector.ParseInstances(raw); err != nil {
+			return err
+		}
+	}
+	// read the token once so that a missing file or host fails before the sync.
+	source, err := tokenSource(v)
+	if err != nil {
+		return err
+	}
+	if source != nil {
+		if _, err := source.Token(); err != nil {
+			return err
+		}
+	}
+	if v.GetBool(HTTPRecordRedactEmails.FieldName) && v.GetString(HTTPRecordFile.FieldName) == "" {
+		return fmt.Errorf("--%s requires --%s", HTTPRecordRedactEmails.FieldName, HTTPRecordFile.FieldName)
+	}
+	if v.GetInt(InvitationExpiryDays.FieldName) < 0 {
+		return fmt.Errorf("--%s cannot be negative", InvitationExpiryDays.FieldName)
+	}
+	if err := transportConfig(v).Validate(); err != nil {
+		return err
+	}
+	if err := organizationFilter(v).Validate(); err != nil {
+		return err
+	}
+	if err := workspaceFilter(v).Validate(); err != nil {
+		return err
+	}
 	return nil
 }
+
+// tokenSource returns the token source of --token-file or --terraform-credentials-file, nil
+// when the token is passed with --token.
+func tokenSource(v *viper.Viper) (client.TokenSource, error) {
+	if path := v.GetString(TokenFile.FieldName); path != "" {
+		return client.NewFileTokenSource(path), nil
+	}
+	if path := v.GetString(TerraformCredentialsFile.FieldName); path != "" {
+		return client.NewCLICredentialsTokenSource(path, v.GetString(Address.FieldName))
+	}
+	return nil, nil
+}
+
+func transportConfig(v *viper.Viper) *client.TransportConfig {
+	return &client.TransportConfig{
+		CABundleFile:       v.GetString(CABundle.FieldName),
+		ClientCertFile:     v.GetString(ClientCert.FieldName),
+		ClientKeyFile:      v.GetString(ClientKey.FieldName),
+		ProxyURL:           v.GetString(HTTPSProxy.FieldName),
+		InsecureSkipVerify: v.GetBool(InsecureSkipVerify.FieldName),
+	}
+}
+
+func organizationFilter(v *viper.Viper) *client.OrganizationFilter {
+	return &client.OrganizationFilter{
+		Allow: v.GetStringSlice(OrganizationAllowlist.FieldName),
+		Deny:  v.GetStringSlice(OrganizationDenylist.FieldName),
+	}
+}
+
+func workspaceFilter(v *viper.Viper) *client.WorkspaceFilter {
+	return &client.WorkspaceFilter{
+		Projects:    v.GetStringSlice(Projects.FieldName),
+		Tags:        v.GetStringSlice(WorkspaceTags.FieldName),
+		ExcludeTags: v.GetStringSlice(WorkspaceExcludeTags.FieldName),
+	}
+}
@@ -10,11 +10,156 @@ import (
 func TestConfigs(t *testing.T) {
 	configurationSchema := field.NewConfiguration(
 		ConfigurationFields,
-		FieldRelationships...,
+		field.WithConstraints(FieldRelationships...),
 	)
 
 	testCases := []test.TestCase{
-		// Add test cases here.
+		{
+			Configs: map[string]string{
+				"token": "token",
+			},
+			IsValid: true,
+			Message: "token only",
+		},
+		{
+			Configs: map[string]string{},
+			IsValid: false,
+			Message: "missing token",
+		},
+		{
+			Configs: map[string]string{
+				"token":                  "token",
+				"organization-allowlist": "acme-*",
+				"organization-denylist":  "acme-sandbox",
+			},
+			IsValid: true,
+			Message: "organization filters",
+		},
+		{
+			Configs: map[string]string{
+				"token":                  "token",
+				"organization-allowlist": "acme-[",
+			},
+			IsValid: false,
+			Message: "invalid organization pattern",
+		},
+		{
+			Configs: map[string]string{
+				"token":                  "token",
+				"projects":               "platform",
+				"workspace-tags":         "env:prod",
+				"workspace-exclude-tags": "deprecated",
+			},
+			IsValid: true,
+			Message: "workspace filters",
+		},
+		{
+			Configs: map[string]string{
+				"token":          "token",
+				"workspace-tags": "env:prod,team:core",
+			},
+			IsValid: false,
+			Message: "invalid workspace tag",
+		},
+		{
+			Configs: map[string]string{
+				"instances": `[{"token":"token"},{"name":"tfe-eu","address":"https://tfe.example.com","token":"token","organization_allowlist":["acme"]}]`,
+			},
+			IsValid: true,
+			Message: "instances",
+		},
+		{
+			Configs: map[string]string{
+				"token":     "token",
+				"instances": `[{"token":"token"}]`,
+			},
+			IsValid: false,
+			Message: "token and instances",
+		},
+		{
+			Configs: map[string]string{
+				"instances": `[{"token":"token"},{"address":"https://app.terraform.io","token":"other"}]`,
+			},
+			IsValid: false,
+			Message: "duplicate instance names",
+		},
+		{
+			Configs: map[string]string{
+				"instances": `[{"address":"https://tfe.example.com"}]`,
+			},
+			IsValid: false,
+			Message: "instance without token",
+		},
+		{
+			Configs: map[string]string{
+				"token":      "token",
+				"token-file": "/nonexistent/token",
+			},
+			IsValid: false,
+			Message: "token and token file",
+		},
+		{
+			Configs: map[string]string{
+				"token-file": "/nonexistent/token",
+			},
+			IsValid: false,
+			Message: "missing token file",
+		},
+		{
+			Configs: map[string]string{
+				"instances": `[{"token":"token","token_file":"/run/secrets/tfe-token"}]`,
+			},
+			IsValid: false,
+			Message: "instance with token and token file",
+		},
+		{
+			Configs: map[string]string{
+				"token":       "token",
+				"client-cert": "client.pem",
+			},
+			IsValid: false,
+			Message: "client certificate without key",
+		},
+		{
+			Configs: map[string]string{
+				"token":     "token",
+				"ca-bundle": "/nonexistent/ca.pem",
+			},
+			IsValid: false,
+			Message: "missing CA bundle",
+		},
+		{
+			Configs: map[string]string{
+				"token":       "token",
+				"https-proxy": "http://proxy.example.com:3128",
+			},
+			IsValid: true,
+			Message: "proxy",
+		},
+		{
+			Configs: map[string]string{
+				"http-replay-file": "recording.jsonl",
+			},
+			IsValid: true,
+			Message: "replay without token",
+		},
+		{
+			Configs: map[string]string{
+				"token":            "token",
+				"http-record-file": "recording.har",
+				"http-replay-file": "recording.jsonl",
+			},
+			IsValid: false,
+			Message: "record and replay",
+		},
+		{
+			Configs: map[string]string{
+				"token":                     "token",
+				"http-record-redact-emails": "true",
+			},
+			IsValid: false,
+			Message: "redact emails without recording",
+		},
 	}
 
 	test.ExerciseTestCases(t, configurationSchema, ValidateConfig, testCases)
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/config"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-sdk/pkg/metrics"
 	"github.com/conductorone/baton-sdk/pkg/types"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
 	"github.com/conductorone/baton-terraform-cloud/pkg/connector"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/spf13/viper"
@@ -17,6 +22,9 @@ import (
 
 var version = "dev"
 
+// closers release what the connectors built by getConnector hold once the command returns.
+var closers []func(context.Context) error
+
 func main() {
 	ctx := context.Background()
 
@@ -25,7 +33,8 @@ func main() {
 		"baton-terraform-cloud",
 		getConnector,
 		field.Configuration{
-			Fields: ConfigurationFields,
+			Fields:      ConfigurationFields,
+			Constraints: FieldRelationships,
 		},
 	)
 	if err != nil {
@@ -36,6 +45,9 @@ func main() {
 	cmd.Version = version
 
 	err = cmd.Execute()
+	for _, closer := range closers {
+		err = errors.Join(err, closer(ctx))
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
@@ -48,16 +60,90 @@ func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, e
 		return nil, err
 	}
 
-	// cb, err := connector.New(ctx, v.GetString(TokenField.FieldName), v.GetString(OrgID.FieldName), v.GetString(Address.FieldName))
-	cb, err := connector.New(ctx, v.GetString(TokenField.FieldName), v.GetString(Address.FieldName))
+	transport := transportConfig(v)
+	if transport.InsecureSkipVerify {
+		l.Warn("baton-terraform-cloud: TLS certificate verification is disabled, the API token can be intercepted. Do not use --insecure-skip-verify in production")
+	}
+	opts := []client.Option{
+		client.WithTransportConfig(transport),
+		client.WithInvitationExpiry(time.Duration(v.GetInt(InvitationExpiryDays.FieldName)) * 24 * time.Hour),
+	}
+	server := &connectorServer{}
+
+	provider, err := newMeterProvider(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	var builderOpts []connectorbuilder.Opt
+	if provider != nil {
+		handler := metrics.NewOtelHandler(ctx, provider, "baton-terraform-cloud")
+		opts = append(opts, client.WithMetricsHandler(handler))
+		builderOpts = append(builderOpts, connectorbuilder.WithMetricsHandler(handler))
+		server.flush = append(server.flush, provider.ForceFlush)
+		closers = append(closers, provider.Shutdown)
+	}
+
+	if path := v.GetString(HTTPReplayFile.FieldName); path != "" {
+		replay, err := client.NewReplay(path)
+		if err != nil {
+			return nil, err
+		}
+		l.Info("baton-terraform-cloud: serving recorded API responses, the instances are not called", zap.String("file", path))
+		opts = append(opts, client.WithReplay(replay))
+	}
+	if path := v.GetString(HTTPRecordFile.FieldName); path != "" {
+		recorder, err := client.NewRecorder(path, v.GetBool(HTTPRecordRedactEmails.FieldName))
+		if err != nil {
+			return nil, err
+		}
+		l.Warn("baton-terraform-cloud: recording API requests and responses, the file holds user and team data", zap.String("file", path))
+		opts = append(opts, client.WithRecorder(recorder))
+		server.flush = append(server.flush, func(context.Context) error { return recorder.Flush() })
+		closers = append(closers, func(context.Context) error { return recorder.Close() })
+	}
+
+	var cb *connector.Connector
+	if raw := v.GetString(Instances.FieldName); raw != "" {
+		var instances []*connector.InstanceConfig
+		instances, err = connector.ParseInstances(raw)
+		if err != nil {
+			return nil, err
+		}
+		cb, err = connector.NewMultiInstance(ctx, instances, organizationFilter(v), workspaceFilter(v), opts...)
+	} else {
+		var source client.TokenSource
+		source, err = tokenSource(v)
+		if err != nil {
+			return nil, err
+		}
+		if source != nil {
+			opts = append(opts, client.WithTokenSource(source))
+		}
+		cb, err = connector.New(ctx, v.GetString(TokenField.FieldName), v.GetString(Address.FieldName), organizationFilter(v), workspaceFilter(v), opts...)
+	}
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
 	}
-	connector, err := connectorbuilder.NewConnector(ctx, cb)
+	server.ConnectorServer, err = connectorbuilder.NewConnector(ctx, cb, builderOpts...)
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
 	}
-	return connector, nil
+	return server, nil
+}
+
+// connectorServer flushes the metrics and the HTTP recording when a sync ends. The connector service process exits
+// without a shutdown hook once the sync is done.
+type connectorServer struct {
+	types.ConnectorServer
+	flush []func(context.Context) error
+}
+
+func (s *connectorServer) Cleanup(ctx context.Context, req *v2.ConnectorServiceCleanupRequest) (*v2.ConnectorServiceCleanupResponse, error) {
+	resp, err := s.ConnectorServer.Cleanup(ctx, req)
+	for _, flush := range s.flush {
+		err = errors.Join(err, flush(ctx))
+	}
+	return resp, err
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newMeterProvider returns a meter provider exporting to the OpenTelemetry collector the SDK
+// sends its traces to, with the same TLS options. It returns nil when no collector is
+// configured, the SDK only sets up a tracer provider.
+func newMeterProvider(ctx context.Context, v *viper.Viper) (*sdkmetric.MeterProvider, error) {
+	endpoint := v.GetString(field.OtelCollectorEndpointFieldName)
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if !v.GetBool(field.OtelCollectorEndpointTLSInsecureFieldName) {
+		tlsConfig, err := collectorTLSConfig(
+			v.GetString(field.OtelCollectorEndpointTLSCertPathFieldName),
+			v.GetString(field.OtelCollectorEndpointTLSCertFieldName),
+		)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: failed to connect to the otel collector: %w", err)
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithGRPCConn(conn))
+	if err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: failed to create the metric exporter: %w", err)
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceNameKey.String("baton-terraform-cloud")))
+	if err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: failed to create the otel resource: %w", err)
+	}
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+	), nil
+}
+
+// collectorTLSConfig trusts the certificate read from certPath or the base64 encoded PEM
+// cert, the system pool when neither is set.
+func collectorTLSConfig(certPath, cert string) (*tls.Config, error) {
+	var pem []byte
+	switch {
+	case certPath != "" && cert != "":
+		return nil, fmt.Errorf("baton-terraform-cloud: %s and %s are mutually exclusive",
+			field.OtelCollectorEndpointTLSCertPathFieldName, field.OtelCollectorEndpointTLSCertFieldName)
+	case certPath != "":
+		data, err := os.ReadFile(certPath)
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: failed to read the otel collector certificate: %w", err)
+		}
+		pem = data
+	case cert != "":
+		data, err := base64.RawURLEncoding.DecodeString(cert)
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: failed to decode the otel collector certificate: %w", err)
+		}
+		pem = data
+	default:
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: failed to load the system certificate pool: %w", err)
+		}
+		return &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("baton-terraform-cloud: failed to parse the otel collector certificate")
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}, nil
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+var (
+	// CacheTTL bounds how long an entry may be served, so long-running syncs and service
+	// mode never report data older than this.
+	CacheTTL = time.Hour
+
+	// CacheMaxEntries bounds the size of the cache. The least recently used entries are
+	// evicted first.
+	CacheMaxEntries = 50000
+)
+
+type cacheEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// Cache is a size bounded LRU cache with a TTL, shared by the resource builders through the
+// client. It is cleared when a sync starts. Builders read expired and evicted entries again
+// from the API, the bounds cost requests but never drop data, so the cache must not hold
+// state that cannot be read again.
+type Cache struct {
+	m          sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+}
+
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+func (c *Cache) Get(key string) (any, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && c.now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *Cache) Set(key string, value any) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	entry := &cacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Clear drops every entry.
+func (c *Cache) Clear() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *Cache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.order.Len()
+}
+
+// CacheGet returns the cached value for key if it holds a T.
+func CacheGet[T any](c *Cache, key string) (T, bool) {
+	var zero T
+	value, ok := c.Get(key)
+	if !ok {
+		return zero, false
+	}
+	rv, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return rv, true
+}
+
+// cachedResult is a response shared by CachedCall, with the error when the token cannot read it.
+type cachedResult[T any] struct {
+	value T
+	err   error
+}
+
+// CachedCall returns the result of call shared under key for the rest of the sync. Responses
+// the token is denied are shared too, so a denied listing is requested once. Other errors are
+// not cached.
+func CachedCall[T any](c *Cache, key string, call func() (T, error)) (T, error) {
+	if result, ok := CacheGet[cachedResult[T]](c, key); ok {
+		return result.value, result.err
+	}
+
+	value, err := call()
+	if err == nil || IsAccessDenied(err) {
+		c.Set(key, cachedResult[T]{value: value, err: err})
+	}
+	return value, err
+}
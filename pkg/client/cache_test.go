@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(time.Hour, 2)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("expected recently used entry to be kept")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Errorf("expected empty cache after Clear, got %d entries", cache.Len())
+	}
+}
+
+func TestCacheGetWrongType(t *testing.T) {
+	cache := NewCache(time.Hour, 10)
+	cache.Set("key", 1)
+	if _, ok := CacheGet[string](cache, "key"); ok {
+		t.Errorf("expected a type mismatch to be a miss")
+	}
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	now := time.Now()
+	cache := NewCache(time.Minute, 10)
+	cache.now = func() time.Time { return now }
+	cache.Set("key", 1)
+
+	now = now.Add(59 * time.Second)
+	if _, ok := cache.Get("key"); !ok {
+		t.Errorf("expected the entry to be served before its TTL")
+	}
+	now = now.Add(2 * time.Second)
+	if _, ok := cache.Get("key"); ok {
+		t.Errorf("expected the entry to expire after its TTL")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected the expired entry to be dropped, got %d entries", cache.Len())
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// TokenType is the kind of API token the client authenticates with.
+// https://developer.hashicorp.com/terraform/cloud-docs/users-teams-organizations/api-tokens
+type TokenType string
+
+const (
+	TokenTypeUser         TokenType = "user"
+	TokenTypeTeam         TokenType = "team"
+	TokenTypeOrganization TokenType = "organization"
+)
+
+// Feature is an organization entitlement that some resource syncers depend on.
+type Feature string
+
+const (
+	FeatureTeams  Feature = "teams"
+	FeatureAgents Feature = "agents"
+	FeatureSSO    Feature = "sso"
+)
+
+// TokenDetails returns the type of the configured token and, for user and team tokens,
+// the account it belongs to. Organization tokens cannot read account details, the
+// endpoint answers with a 404 for them.
+func (c *Client) TokenDetails(ctx context.Context) (TokenType, *tfe.User, error) {
+	type tokenDetails struct {
+		tokenType TokenType
+		user      *tfe.User
+	}
+	if details, ok := CacheGet[tokenDetails](c.Cache, "token-details"); ok {
+		return details.tokenType, details.user, nil
+	}
+
+	tokenType, user, err := c.readTokenDetails(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	c.Cache.Set("token-details", tokenDetails{tokenType: tokenType, user: user})
+	return tokenType, user, nil
+}
+
+func (c *Client) readTokenDetails(ctx context.Context) (TokenType, *tfe.User, error) {
+	user, err := Call(ctx, func(ctx context.Context) (*tfe.User, error) {
+		return c.Users.ReadCurrent(ctx)
+	})
+	if err != nil {
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			return TokenTypeOrganization, nil, nil
+		}
+		return "", nil, err
+	}
+
+	if !user.IsServiceAccount {
+		return TokenTypeUser, user, nil
+	}
+
+	// service accounts backing team tokens are named api-team_<id>,
+	// those backing organization tokens api-org-<org>-<id>.
+	if strings.HasPrefix(user.Username, "api-org-") {
+		return TokenTypeOrganization, user, nil
+	}
+	return TokenTypeTeam, user, nil
+}
+
+// OrganizationEntitlements returns the plan entitlements of an organization.
+func (c *Client) OrganizationEntitlements(ctx context.Context, organization string) (*tfe.Entitlements, error) {
+	key := "entitlements:" + organization
+	if entitlements, ok := CacheGet[*tfe.Entitlements](c.Cache, key); ok {
+		return entitlements, nil
+	}
+
+	entitlements, err := Call(ctx, func(ctx context.Context) (*tfe.Entitlements, error) {
+		return c.Organizations.ReadEntitlements(ctx, organization)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.Cache.Set(key, entitlements)
+	return entitlements, nil
+}
+
+// FeatureEnabled reports whether the organization's plan includes the feature.
+func (c *Client) FeatureEnabled(ctx context.Context, organization string, feature Feature) (bool, error) {
+	entitlements, err := c.OrganizationEntitlements(ctx, organization)
+	if err != nil {
+		return false, err
+	}
+
+	switch feature {
+	case FeatureTeams:
+		return entitlements.Teams, nil
+	case FeatureAgents:
+		return entitlements.Agents, nil
+	case FeatureSSO:
+		return entitlements.SSO, nil
+	default:
+		return true, nil
+	}
+}
@@ -1,6 +1,10 @@
 package client
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/hashicorp/go-tfe"
 )
 
@@ -8,27 +12,104 @@ var (
 	PageSize = 100
 )
 
+// DefaultAddress is the address of HCP Terraform.
+const DefaultAddress = "https://app.terraform.io"
+
 type Client struct {
 	// https://app.terraform.io
 	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs
 	*tfe.Client
+
+	organizationFilter *OrganizationFilter
+	workspaceFilter    *WorkspaceFilter
+	invitationExpiry   time.Duration
+
+	// Cache holds data shared between builders during a sync.
+	Cache *Cache
+	// SyncGaps holds what the builders skipped during a sync.
+	SyncGaps *SyncGaps
+
+	tokenSource     TokenSource
+	transportConfig *TransportConfig
+	metrics         *requestMetrics
+	recorder        *Recorder
+	replay          *Replay
+}
+
+type Option func(c *Client)
+
+// WithOrganizationFilter restricts the organizations the client may sync and provision.
+func WithOrganizationFilter(filter *OrganizationFilter) Option {
+	return func(c *Client) {
+		c.organizationFilter = filter
+	}
+}
+
+// WithWorkspaceFilter restricts the projects and workspaces the client syncs.
+func WithWorkspaceFilter(filter *WorkspaceFilter) Option {
+	return func(c *Client) {
+		c.workspaceFilter = filter
+	}
 }
 
-func New(token, address string) (*Client, error) {
+// WithTokenSource reads the API token from source before every request, instead of the
+// token passed to New.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+func New(token, address string, opts ...Option) (*Client, error) {
+	rv := &Client{
+		Cache:            NewCache(CacheTTL, CacheMaxEntries),
+		SyncGaps:         NewSyncGaps(),
+		invitationExpiry: DefaultInvitationExpiry,
+	}
+	for _, opt := range opts {
+		opt(rv)
+	}
+
+	var transport http.RoundTripper = rv.replay
+	if rv.replay == nil {
+		base, err := rv.transportConfig.transport()
+		if err != nil {
+			return nil, err
+		}
+		transport = base
+	} else if token == "" && rv.tokenSource == nil {
+		// replayed responses do not need a token, go-tfe refuses to start without one.
+		token = "replay"
+	}
+	if rv.recorder != nil {
+		transport = rv.recorder.transport(transport)
+	}
+	if rv.tokenSource != nil {
+		// go-tfe requires a token up front, it is replaced on every request.
+		initial, err := rv.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API token: %w", err)
+		}
+		token = initial
+		transport = &tokenTransport{base: transport, source: rv.tokenSource}
+	}
+
 	config := &tfe.Config{
 		// defaults to https://app.terraform.io
 		Address:           address,
 		Token:             token,
 		RetryServerErrors: true,
+		HTTPClient: &http.Client{
+			Transport: newTracingTransport(transport),
+		},
 	}
 
 	client, err := tfe.NewClient(config)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
-		Client: client,
-	}, nil
+	rv.Client = client
+	return rv, nil
 }
 
 func ListOptions(pageNumber int) tfe.ListOptions {
@@ -37,3 +118,9 @@ func ListOptions(pageNumber int) tfe.ListOptions {
 		PageSize:   PageSize,
 	}
 }
+
+// Address returns the base address of the Terraform instance.
+func (c *Client) Address() string {
+	u := c.BaseURL()
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource returns the API token to send with each request.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// watchedFile caches a value parsed from a file and parses it again when the file's
+// modification time or size changes, so rotated tokens are picked up without a restart.
+type watchedFile struct {
+	m       sync.Mutex
+	path    string
+	parse   func(data []byte) (string, error)
+	value   string
+	modTime time.Time
+	size    int64
+}
+
+func (w *watchedFile) Token() (string, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return "", err
+	}
+	if w.value != "" && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return w.value, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return "", err
+	}
+	value, err := w.parse(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", w.path, err)
+	}
+	w.value = value
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return value, nil
+}
+
+// NewFileTokenSource reads the token from a file holding only the token.
+func NewFileTokenSource(path string) TokenSource {
+	return &watchedFile{
+		path: expandHome(path),
+		parse: func(data []byte) (string, error) {
+			token := strings.TrimSpace(string(data))
+			if token == "" {
+				return "", fmt.Errorf("the token file is empty")
+			}
+			return token, nil
+		},
+	}
+}
+
+// cliCredentials is the format of the Terraform CLI credentials file.
+// https://developer.hashicorp.com/terraform/cli/config/config-file#credentials-1
+type cliCredentials struct {
+	Credentials map[string]struct {
+		Token string `json:"token"`
+	} `json:"credentials"`
+}
+
+// NewCLICredentialsTokenSource reads the token stored for the host of address in a Terraform
+// CLI credentials.tfrc.json file, as written by terraform login.
+func NewCLICredentialsTokenSource(path, address string) (TokenSource, error) {
+	host, err := addressHost(address)
+	if err != nil {
+		return nil, err
+	}
+	return &watchedFile{
+		path: expandHome(path),
+		parse: func(data []byte) (string, error) {
+			credentials := &cliCredentials{}
+			if err := json.Unmarshal(data, credentials); err != nil {
+				return "", fmt.Errorf("invalid credentials file: %w", err)
+			}
+			token := credentials.Credentials[host].Token
+			if token == "" {
+				return "", fmt.Errorf("no credentials for %s", host)
+			}
+			return token, nil
+		},
+	}, nil
+}
+
+func addressHost(address string) (string, error) {
+	if address == "" {
+		address = DefaultAddress
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid address %q", address)
+	}
+	return u.Host, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// tokenTransport sets the Authorization header from a TokenSource on every request,
+// replacing the static token go-tfe was created with.
+type tokenTransport struct {
+	base   http.RoundTripper
+	source TokenSource
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenSourceReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source := NewFileTokenSource(path)
+	if token, err := source.Token(); err != nil || token != "first" {
+		t.Fatalf("expected first, got %q %v", token, err)
+	}
+
+	if err := os.WriteFile(path, []byte("second"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// make the change visible on file systems with a coarse modification time.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := source.Token(); err != nil || token != "second" {
+		t.Errorf("expected the rotated token, got %q %v", token, err)
+	}
+}
+
+func TestCLICredentialsTokenSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.tfrc.json")
+	content := `{"credentials":{"app.terraform.io":{"token":"cloud"},"tfe.example.com":{"token":"enterprise"}}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for address, expected := range map[string]string{
+		"":                        "cloud",
+		"https://tfe.example.com": "enterprise",
+	} {
+		source, err := NewCLICredentialsTokenSource(path, address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token, err := source.Token(); err != nil || token != expected {
+			t.Errorf("expected %s for %q, got %q %v", expected, address, token, err)
+		}
+	}
+
+	source, err := NewCLICredentialsTokenSource(path, "https://other.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Token(); err == nil {
+		t.Errorf("expected an error for a host without credentials")
+	}
+}
+
+func TestTokenTransportSetsAuthorization(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Transport: &tokenTransport{base: http.DefaultTransport, source: NewFileTokenSource(path)}}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer placeholder")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got != "Bearer secret" {
+		t.Errorf("expected the file token, got %q", got)
+	}
+}
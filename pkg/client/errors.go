@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// APIError is a go-tfe error together with the HTTP status of the response it was
+// created from. go-tfe only has sentinel errors for 401 and 404 responses and returns the
+// error titles of other responses, Call keeps their status next to them.
+type APIError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Call runs a go-tfe call and returns its error as an APIError carrying the HTTP status of
+// the last response the call received. The call is recorded in the request metrics of the
+// context's request scope.
+func Call[T any](ctx context.Context, call func(ctx context.Context) (T, error)) (T, error) {
+	var statusCode atomic.Int64
+	endpoint := &callEndpoint{}
+	scope := requestScopeFromContext(ctx)
+	start := time.Now()
+	rv, err := call(tfe.ContextWithResponseHeaderHook(withCallEndpoint(ctx, endpoint), func(status int, header http.Header) {
+		statusCode.Store(int64(status))
+		scope.recordRateLimit(ctx, endpoint.Load(), status, header)
+	}))
+	scope.recordRequest(ctx, endpoint.Load(), int(statusCode.Load()), time.Since(start))
+	if err != nil && statusCode.Load() >= http.StatusBadRequest {
+		err = &APIError{StatusCode: int(statusCode.Load()), Err: err}
+	}
+	return rv, err
+}
+
+// CallErr is Call for the go-tfe calls returning only an error.
+func CallErr(ctx context.Context, call func(ctx context.Context) error) error {
+	_, err := Call(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, call(ctx)
+	})
+	return err
+}
+
+// ErrorCode returns the gRPC code describing a go-tfe error, from its sentinel errors and
+// the HTTP status kept by Call.
+func ErrorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return s.Code()
+	}
+
+	var apiErr *APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		return codes.NotFound
+	case errors.Is(err, tfe.ErrUnauthorized):
+		// HCP Terraform answers 401 to tokens lacking a permission as well as to invalid tokens.
+		return codes.PermissionDenied
+	case errors.Is(err, tfe.ErrWorkspaceLocked), errors.Is(err, tfe.ErrWorkspaceNotLocked):
+		return codes.FailedPrecondition
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.As(err, &apiErr):
+		return statusCode(apiErr.StatusCode)
+	case errors.As(err, &netErr):
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// statusCode maps the HTTP status of an API error response to a gRPC code.
+func statusCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// IsAccessDenied reports whether err means the API token may not read an endpoint. HCP
+// Terraform answers 404 for most objects a token cannot see.
+func IsAccessDenied(err error) bool {
+	code := ErrorCode(err)
+	return code == codes.PermissionDenied || code == codes.NotFound
+}
+
+// WrapError prefixes err with the connector name and msg, and gives it the gRPC status
+// matching the go-tfe error so the platform can tell the failures apart. The original error
+// can still be matched with errors.Is.
+func WrapError(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return withCode(ErrorCode(err), fmt.Errorf("baton-terraform-cloud: %s: %w", msg, err))
+}
+
+// withCode gives err a gRPC status with code, keeping its message.
+func withCode(code codes.Code, err error) error {
+	return &statusError{code: code, err: err}
+}
+
+type statusError struct {
+	code codes.Code
+	err  error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) GRPCStatus() *status.Status {
+	return status.New(e.code, e.Error())
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected codes.Code
+	}{
+		{tfe.ErrResourceNotFound, codes.NotFound},
+		{tfe.ErrUnauthorized, codes.PermissionDenied},
+		{tfe.ErrWorkspaceLocked, codes.FailedPrecondition},
+		{&APIError{StatusCode: http.StatusForbidden, Err: errors.New("forbidden")}, codes.PermissionDenied},
+		{&APIError{StatusCode: http.StatusConflict, Err: errors.New("conflict")}, codes.AlreadyExists},
+		{&APIError{StatusCode: http.StatusUnprocessableEntity, Err: errors.New("has already been taken")}, codes.InvalidArgument},
+		{&APIError{StatusCode: http.StatusTooManyRequests, Err: errors.New("429 Too Many Requests")}, codes.ResourceExhausted},
+		{&APIError{StatusCode: http.StatusServiceUnavailable, Err: errors.New("503 Service Unavailable")}, codes.Unavailable},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		// the text of an error does not decide its code.
+		{errors.New("403 Forbidden"), codes.Unknown},
+	}
+	for _, testCase := range testCases {
+		if code := ErrorCode(testCase.err); code != testCase.expected {
+			t.Errorf("%q: expected %s, got %s", testCase.err, testCase.expected, code)
+		}
+	}
+}
+
+func TestCallKeepsStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors": [{"status": "403", "title": "forbidden"}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New("token", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Call(context.Background(), func(ctx context.Context) (*tfe.TeamList, error) {
+		return c.Teams.List(ctx, "acme", nil)
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the 403 status to be kept, got %v", err)
+	}
+	if code := ErrorCode(err); code != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %s", code)
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	err := WrapError(tfe.ErrResourceNotFound, "failed to read team")
+	if !errors.Is(err, tfe.ErrResourceNotFound) {
+		t.Errorf("expected the go-tfe error to be kept")
+	}
+	if err.Error() != "baton-terraform-cloud: failed to read team: resource not found" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if code := status.Code(err); code != codes.NotFound {
+		t.Errorf("expected NotFound, got %s", code)
+	}
+
+	// callers wrapping the error further keep the code.
+	if code := ErrorCode(errors.Join(errors.New("context"), err)); code != codes.NotFound {
+		t.Errorf("expected NotFound through wrapping, got %s", code)
+	}
+
+	if WrapError(nil, "failed to read team") != nil {
+		t.Errorf("expected nil for a nil error")
+	}
+}
+
+func TestCheckOrganizationPermissionDenied(t *testing.T) {
+	c := &Client{organizationFilter: &OrganizationFilter{Allow: []string{"acme"}}}
+	err := c.CheckOrganization("other")
+	if !errors.Is(err, ErrOrganizationOutOfScope) {
+		t.Errorf("expected ErrOrganizationOutOfScope, got %v", err)
+	}
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %s", code)
+	}
+}
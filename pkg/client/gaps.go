@@ -0,0 +1,49 @@
+package client
+
+import (
+	"slices"
+	"sync"
+)
+
+// SyncGaps records what the API token could not read during a sync, by organization. Unlike
+// the cache it is never evicted, so no skipped call is forgotten before the sync ends.
+type SyncGaps struct {
+	mu   sync.Mutex
+	gaps map[string][]string
+}
+
+func NewSyncGaps() *SyncGaps {
+	return &SyncGaps{gaps: make(map[string][]string)}
+}
+
+// Record adds a gap to the organization, once.
+func (g *SyncGaps) Record(organization, gap string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !slices.Contains(g.gaps[organization], gap) {
+		g.gaps[organization] = append(g.gaps[organization], gap)
+	}
+}
+
+// Organization returns the gaps recorded for the organization, in the order they were found.
+func (g *SyncGaps) Organization(organization string) []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.gaps[organization])
+}
+
+// Clear drops every recorded gap.
+func (g *SyncGaps) Clear() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.gaps = make(map[string][]string)
+}
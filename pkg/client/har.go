@@ -0,0 +1,183 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// The subset of HAR 1.2 written by a Recorder, so recordings open in browser dev tools and
+// HAR viewers. http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Log harContent `json:"log"`
+}
+
+type harContent struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Error is a custom field holding the transport error of a request without response.
+	Error string `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harBody        `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func toHAR(exchanges []*recordedExchange) *harLog {
+	rv := &harLog{
+		Log: harContent{
+			Version: "1.2",
+			Creator: harCreator{Name: instrumentationName, Version: "1"},
+			Entries: make([]*harEntry, 0, len(exchanges)),
+		},
+	}
+	for _, e := range exchanges {
+		entry := &harEntry{
+			StartedDateTime: e.Time,
+			Time:            float64(e.DurationMS),
+			Request: harRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(e.RequestHeaders),
+				QueryString: harQuery(e.URL),
+				HeadersSize: -1,
+				BodySize:    len(e.RequestBody),
+			},
+			Response: harResponse{
+				Status:      e.Status,
+				StatusText:  http.StatusText(e.Status),
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(e.ResponseHeaders),
+				Content: harBody{
+					Size:     len(e.ResponseBody),
+					MimeType: e.ResponseHeaders.Get("Content-Type"),
+					Text:     e.ResponseBody,
+				},
+				HeadersSize: -1,
+				BodySize:    len(e.ResponseBody),
+			},
+			Timings: harTimings{Wait: float64(e.DurationMS)},
+			Error:   e.Error,
+		}
+		if e.RequestBody != "" {
+			entry.Request.PostData = &harPostData{MimeType: e.RequestHeaders.Get("Content-Type"), Text: e.RequestBody}
+		}
+		rv.Log.Entries = append(rv.Log.Entries, entry)
+	}
+	return rv
+}
+
+func fromHAR(log *harLog) []*recordedExchange {
+	rv := make([]*recordedExchange, 0, len(log.Log.Entries))
+	for _, entry := range log.Log.Entries {
+		e := &recordedExchange{
+			Time:            entry.StartedDateTime,
+			DurationMS:      int64(entry.Time),
+			Method:          entry.Request.Method,
+			URL:             entry.Request.URL,
+			RequestHeaders:  httpHeaders(entry.Request.Headers),
+			Status:          entry.Response.Status,
+			ResponseHeaders: httpHeaders(entry.Response.Headers),
+			ResponseBody:    entry.Response.Content.Text,
+			Error:           entry.Error,
+		}
+		if entry.Request.PostData != nil {
+			e.RequestBody = entry.Request.PostData.Text
+		}
+		rv = append(rv, e)
+	}
+	return rv
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	rv := []harNameValue{}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			rv = append(rv, harNameValue{Name: name, Value: value})
+		}
+	}
+	return rv
+}
+
+func harQuery(rawURL string) []harNameValue {
+	rv := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rv
+	}
+	return append(rv, harHeaders(http.Header(u.Query()))...)
+}
+
+func httpHeaders(values []harNameValue) http.Header {
+	rv := http.Header{}
+	for _, v := range values {
+		rv.Add(v.Name, v.Value)
+	}
+	return rv
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// OrganizationMembership mirrors tfe.OrganizationMembership but also decodes
+// the created-at attribute, which go-tfe drops. For invited members this is
+// the date the invitation was sent.
+type OrganizationMembership struct {
+	ID        string                           `jsonapi:"primary,organization-memberships"`
+	Status    tfe.OrganizationMembershipStatus `jsonapi:"attr,status"`
+	Email     string                           `jsonapi:"attr,email"`
+	CreatedAt time.Time                        `jsonapi:"attr,created-at,iso8601"`
+
+	// Relations
+	Organization *tfe.Organization `jsonapi:"relation,organization"`
+	User         *tfe.User         `jsonapi:"relation,user"`
+	Teams        []*tfe.Team       `jsonapi:"relation,teams"`
+}
+
+// DefaultInvitationExpiry is how long an organization invitation is assumed to stay valid.
+// The API does not expose the invitation lifetime of an instance.
+const DefaultInvitationExpiry = 7 * 24 * time.Hour
+
+// WithInvitationExpiry sets how long an organization invitation stays valid before the
+// invitee has to be invited again. Invitations are never reported expired when it is 0.
+func WithInvitationExpiry(expiry time.Duration) Option {
+	return func(c *Client) {
+		c.invitationExpiry = expiry
+	}
+}
+
+// InvitationExpired reports whether the membership is a pending invitation older than the
+// invitation expiry.
+func (c *Client) InvitationExpired(membership *OrganizationMembership) bool {
+	if c.invitationExpiry <= 0 || membership.Status != tfe.OrganizationMembershipInvited || membership.CreatedAt.IsZero() {
+		return false
+	}
+	return time.Since(membership.CreatedAt) > c.invitationExpiry
+}
+
+type OrganizationMembershipList struct {
+	*tfe.Pagination
+	Items []*OrganizationMembership
+}
+
+// ListOrganizationMemberships lists the memberships of an organization.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organization-memberships#list-memberships-for-an-organization
+func (c *Client) ListOrganizationMemberships(ctx context.Context, organization string, options *tfe.OrganizationMembershipListOptions) (*OrganizationMembershipList, error) {
+	u := fmt.Sprintf("organizations/%s/organization-memberships", url.PathEscape(organization))
+	req, err := c.NewRequest("GET", u, options)
+	if err != nil {
+		return nil, err
+	}
+
+	ml := &OrganizationMembershipList{}
+	err = CallErr(ctx, func(ctx context.Context) error {
+		return req.Do(ctx, ml)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ml, nil
+}
+
+// CachedOrganizationMemberships returns a page of organization memberships with their users
+// and teams included, sharing the page between the builders that need it during a sync.
+func (c *Client) CachedOrganizationMemberships(ctx context.Context, organization string, page int) (*OrganizationMembershipList, error) {
+	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organization-memberships
+	return CachedCall(c.Cache, fmt.Sprintf("memberships:%s:%d", organization, page), func() (*OrganizationMembershipList, error) {
+		return c.ListOrganizationMemberships(ctx, organization, &tfe.OrganizationMembershipListOptions{
+			Include:     []tfe.OrgMembershipIncludeOpt{tfe.OrgMembershipUser, tfe.OrgMembershipTeam},
+			ListOptions: ListOptions(page),
+		})
+	})
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// rateLimitThreshold is the fraction of the limit below which the remaining
+// request budget is reported to the syncer. Throttling and retrying 429 responses is
+// left to go-tfe, which follows the limit announced by the server.
+var rateLimitThreshold = 0.2
+
+const (
+	headerRateLimit     = "X-RateLimit-Limit"
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+)
+
+// rateLimitDescription converts the rate limit headers of a response. It returns nil
+// when the response carries no rate limit information.
+func rateLimitDescription(status int, header http.Header) *v2.RateLimitDescription {
+	limit, limitErr := strconv.ParseInt(header.Get(headerRateLimit), 10, 64)
+	remaining, remainingErr := strconv.ParseInt(header.Get(headerRateRemaining), 10, 64)
+	if status != http.StatusTooManyRequests && (limitErr != nil || remainingErr != nil) {
+		return nil
+	}
+
+	resetAt := time.Now().Add(time.Second)
+	if reset, err := strconv.ParseFloat(header.Get(headerRateReset), 64); err == nil {
+		resetAt = time.Now().Add(time.Duration(reset * float64(time.Second)))
+	}
+
+	rv := &v2.RateLimitDescription{
+		Status:    v2.RateLimitDescription_STATUS_OK,
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   timestamppb.New(resetAt),
+	}
+	if status == http.StatusTooManyRequests {
+		rv.Status = v2.RateLimitDescription_STATUS_OVERLIMIT
+		rv.Remaining = 0
+	}
+	return rv
+}
+
+// RateLimitTracker records the rate limit state of the requests made with its context.
+type RateLimitTracker struct {
+	m    sync.Mutex
+	last *v2.RateLimitDescription
+}
+
+// TrackRateLimit returns a context whose requests report their rate limit headers to the tracker.
+func TrackRateLimit(ctx context.Context) (context.Context, *RateLimitTracker) {
+	t := &RateLimitTracker{}
+	return tfe.ContextWithResponseHeaderHook(ctx, t.observe), t
+}
+
+func (t *RateLimitTracker) observe(status int, header http.Header) {
+	desc := rateLimitDescription(status, header)
+	if desc == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.last = desc
+}
+
+// Annotations returns a RateLimitDescription annotation once the remaining budget falls
+// under the threshold, so the syncer can back off. Otherwise it returns nil.
+func (t *RateLimitTracker) Annotations() annotations.Annotations {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.last == nil {
+		return nil
+	}
+	if t.last.Status == v2.RateLimitDescription_STATUS_OK && float64(t.last.Remaining) > float64(t.last.Limit)*rateLimitThreshold {
+		return nil
+	}
+	return annotations.New(t.last)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+func TestRateLimitDescription(t *testing.T) {
+	header := http.Header{}
+	if desc := rateLimitDescription(http.StatusOK, header); desc != nil {
+		t.Fatalf("expected no description without headers, got %v", desc)
+	}
+
+	header.Set(headerRateLimit, "30")
+	header.Set(headerRateRemaining, "3")
+	header.Set(headerRateReset, "0.25")
+	desc := rateLimitDescription(http.StatusOK, header)
+	if desc.GetStatus() != v2.RateLimitDescription_STATUS_OK || desc.GetLimit() != 30 || desc.GetRemaining() != 3 {
+		t.Errorf("unexpected description %v", desc)
+	}
+
+	desc = rateLimitDescription(http.StatusTooManyRequests, http.Header{})
+	if desc.GetStatus() != v2.RateLimitDescription_STATUS_OVERLIMIT || desc.GetRemaining() != 0 {
+		t.Errorf("unexpected description for 429 %v", desc)
+	}
+}
+
+func TestRateLimitTrackerAnnotations(t *testing.T) {
+	tracker := &RateLimitTracker{}
+	if annos := tracker.Annotations(); annos != nil {
+		t.Fatalf("expected no annotations before any request, got %v", annos)
+	}
+
+	header := http.Header{}
+	header.Set(headerRateLimit, "30")
+	header.Set(headerRateRemaining, "20")
+	tracker.observe(http.StatusOK, header)
+	if annos := tracker.Annotations(); annos != nil {
+		t.Errorf("expected no annotations with plenty of budget left, got %v", annos)
+	}
+
+	header.Set(headerRateRemaining, "2")
+	tracker.observe(http.StatusOK, header)
+	if annos := tracker.Annotations(); !annos.Contains(&v2.RateLimitDescription{}) {
+		t.Errorf("expected a rate limit annotation close to the limit")
+	}
+}
+
+func TestRateLimitedRequestIsRetriedOnce(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		requests++
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		if requests == 1 {
+			w.Header().Set(headerRateLimit, "30")
+			w.Header().Set(headerRateRemaining, "0")
+			w.Header().Set(headerRateReset, "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [], "meta": {"pagination": {"current-page": 1, "total-pages": 1}}}`))
+	}))
+	defer server.Close()
+
+	c, err := New("token", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Organizations.List(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("expected go-tfe to retry the rate limited request once, got %d requests", requests)
+	}
+}
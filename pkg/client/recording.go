@@ -0,0 +1,448 @@
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are replaced in recordings, they carry credentials.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactedKeys are JSON attributes holding credentials, such as the token of a newly created
+// team or agent token.
+var redactedKeys = map[string]bool{
+	"token":       true,
+	"password":    true,
+	"secret":      true,
+	"private-key": true,
+	"hmac-key":    true,
+	"ssh-key":     true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// recordedExchange is a request and its response, one per line of a JSONL recording.
+type recordedExchange struct {
+	Time            time.Time   `json:"time"`
+	DurationMS      int64       `json:"duration_ms"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers,omitempty"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// redactor removes credentials, and optionally email addresses, from recorded exchanges.
+type redactor struct {
+	emails bool
+	// salt keeps email pseudonyms stable within a recording without making them
+	// reversible by hashing a list of known addresses.
+	salt []byte
+}
+
+func (r *redactor) headers(header http.Header) http.Header {
+	rv := header.Clone()
+	for _, name := range redactedHeaders {
+		if rv.Get(name) != "" {
+			rv.Set(name, redacted)
+		}
+	}
+	// bodies can change length once redacted.
+	rv.Del("Content-Length")
+	return rv
+}
+
+func (r *redactor) url(u *url.URL) string {
+	if !r.emails || u.RawQuery == "" {
+		return u.String()
+	}
+	rv := *u
+	query := rv.Query()
+	for key, values := range query {
+		for i := range values {
+			values[i] = r.text(values[i])
+		}
+		query[key] = values
+	}
+	rv.RawQuery = query.Encode()
+	return rv.String()
+}
+
+// body redacts the secrets and, for JSON bodies, the credential attributes and the value of
+// sensitive variables.
+func (r *redactor) body(data []byte, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			data = bytes.ReplaceAll(data, []byte(secret), []byte(redacted))
+		}
+	}
+	if !json.Valid(data) {
+		return r.text(string(data))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return r.text(string(data))
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(r.walk(v)); err != nil {
+		return r.text(string(data))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func (r *redactor) walk(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		// variables and variable set variables hide their value when sensitive.
+		sensitive, _ := v["sensitive"].(bool)
+		for key, child := range v {
+			_, isString := child.(string)
+			if (redactedKeys[key] && isString) || (sensitive && key == "value" && child != nil) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = r.walk(child)
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = r.walk(v[i])
+		}
+		return v
+	case string:
+		return r.text(v)
+	default:
+		return v
+	}
+}
+
+// text replaces email addresses with pseudonyms, the same address always gets the same
+// pseudonym so memberships still line up in the recording.
+func (r *redactor) text(s string) string {
+	if !r.emails {
+		return s
+	}
+	return emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		mac := hmac.New(sha256.New, r.salt)
+		mac.Write([]byte(strings.ToLower(email)))
+		return "redacted-" + hex.EncodeToString(mac.Sum(nil))[:12] + "@example.invalid"
+	})
+}
+
+// Recorder writes every request and response sent through the clients it is passed to, with
+// credentials redacted, for debugging. Recordings can be served back with a Replay.
+type Recorder struct {
+	m    sync.Mutex
+	path string
+	// file is the JSON lines stream the exchanges are appended to. HAR recordings stream to
+	// a file next to path and are converted when flushed.
+	file     *os.File
+	har      bool
+	redactor *redactor
+}
+
+// NewRecorder records to path, as a HAR file when the path ends with .har and as JSON lines
+// otherwise. Email addresses are replaced with pseudonyms when redactEmails is set.
+func NewRecorder(path string, redactEmails bool) (*Recorder, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	path = expandHome(path)
+	har := isHARFile(path)
+	stream := path
+	if har {
+		stream = harStreamPath(path)
+	}
+	// the connector service process records to the same stream as the command that starts it.
+	file, err := os.OpenFile(stream, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the recording: %w", err)
+	}
+	return &Recorder{
+		path:     path,
+		file:     file,
+		har:      har,
+		redactor: &redactor{emails: redactEmails, salt: salt},
+	}, nil
+}
+
+// harStreamPath is the JSON lines stream of a HAR recording.
+func harStreamPath(path string) string {
+	return path + ".jsonl"
+}
+
+// WithRecorder records the requests of the client with recorder.
+func WithRecorder(recorder *Recorder) Option {
+	return func(c *Client) {
+		c.recorder = recorder
+	}
+}
+
+// Flush writes the HAR file from the exchanges recorded so far. JSON lines recordings are
+// always complete.
+func (r *Recorder) Flush() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.flush()
+}
+
+func (r *Recorder) flush() error {
+	if !r.har {
+		return nil
+	}
+	stream := harStreamPath(r.path)
+	data, err := os.ReadFile(stream)
+	if err != nil {
+		return fmt.Errorf("failed to read the recording: %w", err)
+	}
+	exchanges, err := decodeExchanges(stream, data)
+	if err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(toHAR(exchanges), "", "  ")
+	if err != nil {
+		return err
+	}
+	// the HAR file is replaced at once so it stays readable.
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Close flushes the recording and closes it, removing the stream of a HAR recording.
+func (r *Recorder) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	err := errors.Join(r.flush(), r.file.Close())
+	if err == nil && r.har {
+		err = os.Remove(harStreamPath(r.path))
+	}
+	return err
+}
+
+// write appends the exchange to the recording stream.
+func (r *Recorder) write(exchange *recordedExchange) error {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	_, err = r.file.Write(append(data, '\n'))
+	return err
+}
+
+func (r *Recorder) transport(base http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{base: base, recorder: r}
+}
+
+type recordingTransport struct {
+	base     http.RoundTripper
+	recorder *Recorder
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	// the token is also redacted wherever the API echoes it back.
+	secrets := []string{strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")}
+
+	redactor := t.recorder.redactor
+	exchange := &recordedExchange{
+		Time:           time.Now().UTC(),
+		Method:         req.Method,
+		URL:            redactor.url(req.URL),
+		RequestHeaders: redactor.headers(req.Header),
+		RequestBody:    redactor.body(requestBody, secrets),
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	exchange.DurationMS = time.Since(exchange.Time).Milliseconds()
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		responseBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+		if readErr != nil {
+			return nil, readErr
+		}
+		exchange.Status = resp.StatusCode
+		exchange.ResponseHeaders = redactor.headers(resp.Header)
+		exchange.ResponseBody = redactor.body(responseBody, secrets)
+	}
+
+	if writeErr := t.recorder.write(exchange); writeErr != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("failed to record %s %s: %w", req.Method, req.URL.Path, writeErr)
+	}
+	return resp, err
+}
+
+// Replay serves the responses of a recording instead of calling the instance, so a sync can
+// be reproduced offline.
+type Replay struct {
+	m sync.Mutex
+	// exchanges are keyed by method and URL, and by method and URL without the host for
+	// recordings made against another address.
+	exchanges map[string][]*recordedExchange
+	served    map[string]int
+}
+
+// NewReplay loads the recording written by a Recorder at path.
+func NewReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recording: %w", err)
+	}
+
+	var exchanges []*recordedExchange
+	if isHARFile(path) {
+		log := &harLog{}
+		if err := json.Unmarshal(data, log); err != nil {
+			return nil, fmt.Errorf("%s: invalid HAR file: %w", path, err)
+		}
+		exchanges = fromHAR(log)
+	} else {
+		exchanges, err = decodeExchanges(path, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv := &Replay{
+		exchanges: make(map[string][]*recordedExchange),
+		served:    make(map[string]int),
+	}
+	for _, exchange := range exchanges {
+		u, err := url.Parse(exchange.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid URL %q: %w", path, exchange.URL, err)
+		}
+		for _, key := range replayKeys(exchange.Method, u) {
+			rv.exchanges[key] = append(rv.exchanges[key], exchange)
+		}
+	}
+	return rv, nil
+}
+
+// decodeExchanges decodes a JSON lines recording read from path.
+func decodeExchanges(path string, data []byte) ([]*recordedExchange, error) {
+	var rv []*recordedExchange
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		exchange := &recordedExchange{}
+		if err := decoder.Decode(exchange); err != nil {
+			return nil, fmt.Errorf("%s: invalid recording: %w", path, err)
+		}
+		rv = append(rv, exchange)
+	}
+	return rv, nil
+}
+
+// WithReplay serves the requests of the client from replay instead of the network.
+func WithReplay(replay *Replay) Option {
+	return func(c *Client) {
+		c.replay = replay
+	}
+}
+
+func replayKeys(method string, u *url.URL) []string {
+	return []string{
+		method + " " + u.Host + u.RequestURI(),
+		method + " " + u.RequestURI(),
+	}
+}
+
+// RoundTrip returns the recorded responses of a request in the order they were recorded,
+// repeating the last one once they are all served.
+func (r *Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.m.Lock()
+	var exchange *recordedExchange
+	for _, key := range replayKeys(req.Method, req.URL) {
+		exchanges := r.exchanges[key]
+		if len(exchanges) == 0 {
+			continue
+		}
+		i := min(r.served[key], len(exchanges)-1)
+		r.served[key]++
+		exchange = exchanges[i]
+		break
+	}
+	r.m.Unlock()
+
+	if exchange == nil {
+		// a 400 is not retried by go-tfe and its title becomes the error message.
+		return replayResponse(req, http.StatusBadRequest, http.Header{"Content-Type": {"application/vnd.api+json"}},
+			fmt.Sprintf(`{"errors":[{"status":"400","title":"no recorded response for %s %s"}]}`, req.Method, req.URL.RequestURI())), nil
+	}
+	if exchange.Error != "" {
+		return nil, fmt.Errorf("recorded error: %s", exchange.Error)
+	}
+	return replayResponse(req, exchange.Status, exchange.ResponseHeaders.Clone(), exchange.ResponseBody), nil
+}
+
+func replayResponse(req *http.Request, status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func isHARFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".har")
+}
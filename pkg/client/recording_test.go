@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const recordedOrganization = `{"data":{"id":"acme","type":"organizations","attributes":{"name":"acme","email":"owner@acme.example.com"}}}`
+
+func TestRedactorBody(t *testing.T) {
+	r := &redactor{emails: true, salt: []byte("salt")}
+	body := r.body([]byte(`{"data":[
+		{"type":"vars","attributes":{"key":"AWS_SECRET","value":"hunter2","sensitive":true}},
+		{"type":"vars","attributes":{"key":"REGION","value":"eu-west-1","sensitive":false}},
+		{"type":"authentication-tokens","attributes":{"token":"abc.atlasv1.def","description":"echo s3cr3t-token"}},
+		{"type":"users","attributes":{"email":"Jane@Example.com","username":"jane"}},
+		{"type":"users","attributes":{"email":"jane@example.com","username":"jane2"}}
+	]}`), []string{"s3cr3t-token"})
+
+	for _, leaked := range []string{"hunter2", "abc.atlasv1.def", "s3cr3t-token", "example.com"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("expected %q to be redacted from %s", leaked, body)
+		}
+	}
+	if !strings.Contains(body, "eu-west-1") {
+		t.Errorf("expected the value of a variable that is not sensitive to be kept: %s", body)
+	}
+	pseudonym := r.text("jane@example.com")
+	if strings.Count(body, pseudonym) != 2 {
+		t.Errorf("expected both spellings of the email to get the pseudonym %s: %s", pseudonym, body)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, name := range []string{"recording.jsonl", "recording.har"} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				w.Header().Set("TFP-AppName", "HCP Terraform")
+				if r.URL.Path == "/api/v2/ping" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				_, _ = w.Write([]byte(recordedOrganization))
+			}))
+
+			path := filepath.Join(t.TempDir(), name)
+			recorder, err := NewRecorder(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := New("secret-token", server.URL, WithRecorder(recorder))
+			if err != nil {
+				t.Fatal(err)
+			}
+			org, err := c.Organizations.Read(context.Background(), "acme")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if org.Email != "owner@acme.example.com" {
+				t.Errorf("expected the client to get the response as sent, got %s", org.Email)
+			}
+			if isHARFile(path) {
+				// the HAR file is only written when the recording is flushed.
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("expected no HAR file before the recording is flushed, got %v", err)
+				}
+				if err := recorder.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := NewReplay(path); err != nil {
+					t.Errorf("expected a readable HAR file once flushed, got %v", err)
+				}
+			}
+			if err := recorder.Close(); err != nil {
+				t.Fatal(err)
+			}
+			server.Close()
+			if _, err := os.Stat(harStreamPath(path)); isHARFile(path) && !os.IsNotExist(err) {
+				t.Errorf("expected the HAR stream to be removed once closed, got %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, leaked := range []string{"secret-token", "owner@acme.example.com"} {
+				if strings.Contains(string(data), leaked) {
+					t.Errorf("expected %q to be redacted from the recording", leaked)
+				}
+			}
+
+			replay, err := NewReplay(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err = New("", server.URL, WithReplay(replay))
+			if err != nil {
+				t.Fatal(err)
+			}
+			org, err = c.Organizations.Read(context.Background(), "acme")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if org.Name != "acme" || !strings.HasSuffix(org.Email, "@example.invalid") {
+				t.Errorf("expected the recorded organization, got %s %s", org.Name, org.Email)
+			}
+			if c.Platform() != PlatformHCPTerraform {
+				t.Errorf("expected the recorded ping headers to be replayed, got %s", c.Platform())
+			}
+
+			_, err = c.Teams.Read(context.Background(), "team-6p5jTwJQXwqZBncC")
+			if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+				t.Errorf("expected an error for a request missing from the recording, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// RunListForUserOptions extends tfe.RunListForOrganizationOptions with the filter on the user
+// who created the runs, which go-tfe lacks. Its search[user] matches VCS usernames instead.
+type RunListForUserOptions struct {
+	tfe.RunListForOrganizationOptions
+
+	// Username selects the runs created by the user.
+	Username string `url:"filter[user],omitempty"`
+}
+
+// ListRunsForUser lists the runs of an organization created by a user.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/run#list-runs-in-an-organization
+func (c *Client) ListRunsForUser(ctx context.Context, organization string, options *RunListForUserOptions) (*tfe.OrganizationRunList, error) {
+	u := fmt.Sprintf("organizations/%s/runs", url.PathEscape(organization))
+	req, err := c.NewRequest("GET", u, options)
+	if err != nil {
+		return nil, err
+	}
+
+	rl := &tfe.OrganizationRunList{}
+	err = CallErr(ctx, func(ctx context.Context) error {
+		return req.Do(ctx, rl)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
+)
+
+var ErrOrganizationOutOfScope = errors.New("organization is outside the configured scope")
+
+// OrganizationFilter limits which organizations the connector syncs and provisions.
+// Patterns use path.Match glob syntax, e.g. "acme-*". An empty allowlist allows every
+// organization not matched by the denylist.
+type OrganizationFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// Validate checks that every pattern is a valid glob.
+func (f *OrganizationFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Allow...), f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid organization pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether the named organization is in scope.
+func (f *OrganizationFilter) Allowed(name string) bool {
+	if f == nil {
+		return true
+	}
+	if matchAny(f.Deny, name) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchAny(f.Allow, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrganization returns ErrOrganizationOutOfScope if the client must not touch the organization.
+func (c *Client) CheckOrganization(name string) error {
+	if !c.organizationFilter.Allowed(name) {
+		return withCode(codes.PermissionDenied, fmt.Errorf("%w: %s", ErrOrganizationOutOfScope, name))
+	}
+	return nil
+}
+
+// WorkspaceFilter narrows the sync to named projects and to workspaces matching tag expressions.
+// Tags must all be present on a workspace, ExcludeTags must all be absent. A tag matches a tag
+// name or a key/value tag binding written "key:value", or "key" for a binding without value.
+type WorkspaceFilter struct {
+	Projects    []string
+	Tags        []string
+	ExcludeTags []string
+}
+
+// Validate checks that the tag expressions can be sent to the workspaces API.
+func (f *WorkspaceFilter) Validate() error {
+	for _, tag := range append(append([]string{}, f.Tags...), f.ExcludeTags...) {
+		if tag == "" || strings.ContainsAny(tag, ", ") {
+			return fmt.Errorf("invalid workspace tag %q: tags must be non-empty and contain no commas or spaces", tag)
+		}
+	}
+	for _, project := range f.Projects {
+		// the names are sent comma separated, see ScopedProjectIDs.
+		if strings.TrimSpace(project) == "" || strings.Contains(project, ",") {
+			return fmt.Errorf("invalid project name %q", project)
+		}
+	}
+	return nil
+}
+
+// ProjectInScope reports whether the project is selected by the filter.
+func (c *Client) ProjectInScope(project *tfe.Project) bool {
+	f := c.workspaceFilter
+	if f == nil || len(f.Projects) == 0 {
+		return true
+	}
+	if project == nil {
+		return false
+	}
+	return slices.Contains(f.Projects, project.Name)
+}
+
+// WorkspaceTags returns the workspace's tags, the plain tag names followed by the key/value
+// bindings as "key:value", or the key alone when the binding has no value. The bindings are
+// the effective ones, including those inherited from the project, when they were included.
+func WorkspaceTags(workspace *tfe.Workspace) []string {
+	rv := slices.Clone(workspace.TagNames)
+	for _, binding := range workspace.EffectiveTagBindings {
+		if binding.Value == "" {
+			rv = append(rv, binding.Key)
+			continue
+		}
+		rv = append(rv, binding.Key+":"+binding.Value)
+	}
+	slices.Sort(rv)
+	return slices.Compact(rv)
+}
+
+// WorkspaceInScope reports whether the workspace matches the tag filters, by tag name or tag
+// binding. The project filter is applied when listing, see ScopedProjectIDs.
+func (c *Client) WorkspaceInScope(workspace *tfe.Workspace) bool {
+	f := c.workspaceFilter
+	if f == nil {
+		return true
+	}
+	tags := WorkspaceTags(workspace)
+	for _, tag := range f.Tags {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range f.ExcludeTags {
+		if slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkspaceListOptions returns list options with the excluded tags applied server side. A
+// non-empty projectID restricts the listing to the workspaces of that project. The required
+// tags are matched by WorkspaceInScope only, the tag search of the API matches tag names and
+// would drop the workspaces that carry the tag as a binding.
+func (c *Client) WorkspaceListOptions(projectID string, pageNumber int) *tfe.WorkspaceListOptions {
+	opts := &tfe.WorkspaceListOptions{
+		ListOptions: ListOptions(pageNumber),
+		ProjectID:   projectID,
+		// the current run dates the last activity, the effective tag bindings include the
+		// tags inherited from the project.
+		Include: []tfe.WSIncludeOpt{tfe.WSCurrentRun, tfe.WSEffectiveTagBindings},
+	}
+	f := c.workspaceFilter
+	if f == nil {
+		return opts
+	}
+	opts.ExcludeTags = strings.Join(f.ExcludeTags, ",")
+	return opts
+}
+
+// projectListOptions lists the projects with one of the names. filter[names] takes a comma
+// separated list and returns the projects matching any of them.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/projects#query-parameters
+func projectListOptions(names []string, pageNumber int) *tfe.ProjectListOptions {
+	return &tfe.ProjectListOptions{
+		ListOptions: ListOptions(pageNumber),
+		Name:        strings.Join(names, ","),
+	}
+}
+
+// ScopedProjectIDs returns the IDs of the configured projects that exist in the organization,
+// so their workspaces can be listed project by project. The names are resolved once per sync.
+// It returns nil when the filter selects every project.
+func (c *Client) ScopedProjectIDs(ctx context.Context, organization string) ([]string, error) {
+	f := c.workspaceFilter
+	if f == nil || len(f.Projects) == 0 {
+		return nil, nil
+	}
+	key := "scoped-projects:" + organization
+	if ids, ok := CacheGet[[]string](c.Cache, key); ok {
+		return ids, nil
+	}
+
+	ids := []string{}
+	page := 0
+	for c.Supports(APIFeatureProjects) {
+		projects, err := Call(ctx, func(ctx context.Context) (*tfe.ProjectList, error) {
+			return c.Projects.List(ctx, organization, projectListOptions(f.Projects, page))
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects.Items {
+			if c.ProjectInScope(project) {
+				ids = append(ids, project.ID)
+			}
+		}
+		if projects.Pagination == nil || projects.NextPage == 0 {
+			break
+		}
+		page = projects.NextPage
+	}
+
+	c.Cache.Set(key, ids)
+	return ids, nil
+}
+
+// MissingProjects returns the configured project names that do not exist in any in-scope organization.
+func (c *Client) MissingProjects(ctx context.Context) ([]string, error) {
+	f := c.workspaceFilter
+	if f == nil || len(f.Projects) == 0 {
+		return nil, nil
+	}
+	if !c.Supports(APIFeatureProjects) {
+		return slices.Clone(f.Projects), nil
+	}
+
+	orgs, err := c.ListAllOrganizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	for _, org := range orgs {
+		page := 0
+		for {
+			projects, err := Call(ctx, func(ctx context.Context) (*tfe.ProjectList, error) {
+				return c.Projects.List(ctx, org.Name, projectListOptions(f.Projects, page))
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, project := range projects.Items {
+				found[project.Name] = true
+			}
+			if projects.Pagination == nil || projects.NextPage == 0 {
+				break
+			}
+			page = projects.NextPage
+		}
+	}
+
+	var missing []string
+	for _, name := range f.Projects {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// ListAllOrganizations returns every in-scope organization visible to the token.
+func (c *Client) ListAllOrganizations(ctx context.Context) ([]*tfe.Organization, error) {
+	var rv []*tfe.Organization
+	page := 0
+	for {
+		orgs, err := Call(ctx, func(ctx context.Context) (*tfe.OrganizationList, error) {
+			return c.Organizations.List(ctx, &tfe.OrganizationListOptions{
+				ListOptions: ListOptions(page),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs.Items {
+			if c.organizationFilter.Allowed(org.Name) {
+				rv = append(rv, org)
+			}
+		}
+		if orgs.Pagination == nil || orgs.NextPage == 0 {
+			return rv, nil
+		}
+		page = orgs.NextPage
+	}
+}
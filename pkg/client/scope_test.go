@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestWorkspaceInScope(t *testing.T) {
+	workspace := &tfe.Workspace{
+		TagNames: []string{"team-a"},
+		EffectiveTagBindings: []*tfe.EffectiveTagBinding{
+			{Key: "env", Value: "prod"},
+			{Key: "critical"},
+		},
+	}
+
+	testCases := []struct {
+		message  string
+		filter   *WorkspaceFilter
+		expected bool
+	}{
+		{"no filter", nil, true},
+		{"tag name", &WorkspaceFilter{Tags: []string{"team-a"}}, true},
+		{"key/value binding", &WorkspaceFilter{Tags: []string{"env:prod"}}, true},
+		{"binding without value", &WorkspaceFilter{Tags: []string{"critical"}}, true},
+		{"other binding value", &WorkspaceFilter{Tags: []string{"env:dev"}}, false},
+		{"excluded binding", &WorkspaceFilter{ExcludeTags: []string{"env:prod"}}, false},
+		{"excluded tag name", &WorkspaceFilter{ExcludeTags: []string{"team-a"}}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			c := &Client{workspaceFilter: testCase.filter}
+			if actual := c.WorkspaceInScope(workspace); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestScopedProjectIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set("TFP-AppName", "HCP Terraform")
+		switch r.URL.Path {
+		case "/api/v2/ping":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/organizations/acme/projects":
+			// the names are sent as one comma separated filter[names] value.
+			if names := r.URL.Query()["filter[names]"]; !reflect.DeepEqual(names, []string{"core,network"}) {
+				t.Errorf("expected filter[names]=core,network, got %v", names)
+			}
+			_, _ = w.Write([]byte(`{"data":[{"id":"prj-1","type":"projects","attributes":{"name":"core"}},
+{"id":"prj-2","type":"projects","attributes":{"name":"network"}},
+{"id":"prj-3","type":"projects","attributes":{"name":"core-legacy"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New("token", server.URL, WithWorkspaceFilter(&WorkspaceFilter{Projects: []string{"core", "network"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := c.ScopedProjectIDs(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// projects the API returns for a looser match are dropped.
+	if !reflect.DeepEqual(ids, []string{"prj-1", "prj-2"}) {
+		t.Errorf("expected the IDs of the named projects, got %v", ids)
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
+)
+
+// organizationAccessListOptions lists the team access of a whole organization. The
+// team-workspaces and team-projects endpoints accept an organization filter in place of
+// the workspace or project filter go-tfe sends.
+type organizationAccessListOptions struct {
+	tfe.ListOptions
+	Organization string `url:"filter[organization][name]"`
+}
+
+// teamAccessIndex is the team access of an organization keyed by workspace or project ID.
+// unfiltered is set when the instance rejects the organization filter.
+type teamAccessIndex[T any] struct {
+	items      map[string][]T
+	unfiltered bool
+}
+
+// listOrganizationAccess pages through an organization wide team access listing.
+func listOrganizationAccess[T any](ctx context.Context, c *Client, path, organization string, key func(T) string) (*teamAccessIndex[T], error) {
+	rv := &teamAccessIndex[T]{items: map[string][]T{}}
+	page := 0
+	for {
+		req, err := c.NewRequest("GET", path, &organizationAccessListOptions{
+			ListOptions:  ListOptions(page),
+			Organization: organization,
+		})
+		if err != nil {
+			return nil, err
+		}
+		list := &struct {
+			*tfe.Pagination
+			Items []T
+		}{}
+		err = CallErr(ctx, func(ctx context.Context) error {
+			return req.Do(ctx, list)
+		})
+		if ErrorCode(err) == codes.InvalidArgument {
+			// Terraform Enterprise releases without the organization filter require the workspace or project filter.
+			return &teamAccessIndex[T]{unfiltered: true}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			if id := key(item); id != "" {
+				rv.items[id] = append(rv.items[id], item)
+			}
+		}
+		if list.Pagination == nil || list.NextPage == 0 {
+			return rv, nil
+		}
+		page = list.NextPage
+	}
+}
+
+// WorkspaceTeamAccess returns the team access of a workspace. The team access of the whole
+// organization is listed once per sync and shared by its workspaces, instances that cannot
+// list it by organization are read workspace by workspace.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/team-access
+func (c *Client) WorkspaceTeamAccess(ctx context.Context, organization, workspaceID string) ([]*tfe.TeamAccess, error) {
+	key := "team-access:" + organization
+	index, ok := CacheGet[*teamAccessIndex[*tfe.TeamAccess]](c.Cache, key)
+	if !ok {
+		var err error
+		index, err = listOrganizationAccess(ctx, c, "team-workspaces", organization, func(item *tfe.TeamAccess) string {
+			if item.Team == nil || item.Workspace == nil {
+				return ""
+			}
+			return item.Workspace.ID
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.Cache.Set(key, index)
+	}
+	if !index.unfiltered {
+		return index.items[workspaceID], nil
+	}
+
+	var rv []*tfe.TeamAccess
+	page := 0
+	for {
+		teamAccess, err := Call(ctx, func(ctx context.Context) (*tfe.TeamAccessList, error) {
+			return c.TeamAccess.List(ctx, &tfe.TeamAccessListOptions{
+				WorkspaceID: workspaceID,
+				ListOptions: ListOptions(page),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, teamAccess.Items...)
+		if teamAccess.Pagination == nil || teamAccess.NextPage == 0 {
+			return rv, nil
+		}
+		page = teamAccess.NextPage
+	}
+}
+
+// ProjectTeamAccess returns the team access of a project, listed once per sync for the whole
+// organization like WorkspaceTeamAccess. It returns nothing on instances without project
+// team access.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/project-team-access
+func (c *Client) ProjectTeamAccess(ctx context.Context, organization, projectID string) ([]*tfe.TeamProjectAccess, error) {
+	if !c.Supports(APIFeatureTeamProjectAccess) {
+		return nil, nil
+	}
+	key := "project-access:" + organization
+	index, ok := CacheGet[*teamAccessIndex[*tfe.TeamProjectAccess]](c.Cache, key)
+	if !ok {
+		var err error
+		index, err = listOrganizationAccess(ctx, c, "team-projects", organization, func(item *tfe.TeamProjectAccess) string {
+			if item.Team == nil || item.Project == nil {
+				return ""
+			}
+			return item.Project.ID
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.Cache.Set(key, index)
+	}
+	if !index.unfiltered {
+		return index.items[projectID], nil
+	}
+
+	var rv []*tfe.TeamProjectAccess
+	page := 0
+	for {
+		projectAccess, err := Call(ctx, func(ctx context.Context) (*tfe.TeamProjectAccessList, error) {
+			return c.TeamProjectAccess.List(ctx, tfe.TeamProjectAccessListOptions{
+				ProjectID:   projectID,
+				ListOptions: ListOptions(page),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, projectAccess.Items...)
+		if projectAccess.Pagination == nil || projectAccess.NextPage == 0 {
+			return rv, nil
+		}
+		page = projectAccess.NextPage
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTeamWorkspaces = `{"data":[
+{"id":"tws-1","type":"team-workspaces","attributes":{"access":"write"},"relationships":{
+"team":{"data":{"id":"team-1","type":"teams"}},"workspace":{"data":{"id":"ws-1","type":"workspaces"}}}},
+{"id":"tws-2","type":"team-workspaces","attributes":{"access":"read"},"relationships":{
+"team":{"data":{"id":"team-2","type":"teams"}},"workspace":{"data":{"id":"ws-2","type":"workspaces"}}}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`
+
+func TestWorkspaceTeamAccess(t *testing.T) {
+	for _, organizationFilter := range []bool{true, false} {
+		requests := map[string]int{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			switch {
+			case r.URL.Path == "/api/v2/ping":
+				w.WriteHeader(http.StatusNoContent)
+			case r.URL.Path != "/api/v2/team-workspaces":
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			case r.URL.Query().Has("filter[organization][name]"):
+				requests["organization"]++
+				if !organizationFilter {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					_, _ = w.Write([]byte(`{"errors":[{"status":"422","title":"filter[workspace][id] is required"}]}`))
+					return
+				}
+				_, _ = w.Write([]byte(testTeamWorkspaces))
+			default:
+				requests[r.URL.Query().Get("filter[workspace][id]")]++
+				_, _ = w.Write([]byte(testTeamWorkspaces))
+			}
+		}))
+
+		c, err := New("token", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := c.WorkspaceTeamAccess(context.Background(), "acme", "ws-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if organizationFilter && (len(items) != 1 || items[0].Team.ID != "team-1") {
+			t.Errorf("expected the access of team-1 on ws-1, got %v", items)
+		}
+		if _, err := c.WorkspaceTeamAccess(context.Background(), "acme", "ws-2"); err != nil {
+			t.Fatal(err)
+		}
+
+		if requests["organization"] != 1 {
+			t.Errorf("expected the organization to be listed once, got %d requests", requests["organization"])
+		}
+		if !organizationFilter && (requests["ws-1"] != 1 || requests["ws-2"] != 1) {
+			t.Errorf("expected each workspace to be listed once without the organization filter, got %v", requests)
+		}
+		server.Close()
+	}
+}
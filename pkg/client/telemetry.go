@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "baton-terraform-cloud"
+
+const (
+	requestCounterName      = "baton_terraform_cloud.api_requests"
+	requestCounterDesc      = "number of Terraform API requests by endpoint, status, baton resource type and organization"
+	requestLatencyName      = "baton_terraform_cloud.api_request_latency"
+	requestLatencyDesc      = "latency of Terraform API requests by endpoint, status, baton resource type and organization"
+	rateLimitRemainingName  = "baton_terraform_cloud.api_rate_limit_remaining"
+	rateLimitRemainingDesc  = "requests left in the rate limit window when a Terraform API response is received"
+	organizationPlaceholder = ":organization"
+	idPlaceholder           = ":id"
+)
+
+// WithMetricsHandler records the API request metrics with handler. Without it no metrics
+// are recorded.
+func WithMetricsHandler(handler metrics.Handler) Option {
+	return func(c *Client) {
+		c.metrics = newRequestMetrics(handler)
+	}
+}
+
+// requestMetrics are the instruments recording the API requests of a client.
+type requestMetrics struct {
+	requests           metrics.Int64Counter
+	latency            metrics.Int64Histogram
+	rateLimitRemaining metrics.Int64Histogram
+}
+
+func newRequestMetrics(handler metrics.Handler) *requestMetrics {
+	return &requestMetrics{
+		requests:           handler.Int64Counter(requestCounterName, requestCounterDesc, metrics.Dimensionless),
+		latency:            handler.Int64Histogram(requestLatencyName, requestLatencyDesc, metrics.Milliseconds),
+		rateLimitRemaining: handler.Int64Histogram(rateLimitRemainingName, rateLimitRemainingDesc, metrics.Dimensionless),
+	}
+}
+
+type requestScopeKey struct{}
+
+// requestScope attributes the API requests made with a context to what is being synced or
+// provisioned.
+type requestScope struct {
+	metrics      *requestMetrics
+	resourceType string
+	organization string
+}
+
+// WithRequestScope returns a context whose API requests are recorded for the baton resource
+// type and the organization they are made for. Call records the requests made with it.
+func (c *Client) WithRequestScope(ctx context.Context, resourceType, organization string) context.Context {
+	return context.WithValue(ctx, requestScopeKey{}, &requestScope{
+		metrics:      c.metrics,
+		resourceType: resourceType,
+		organization: organization,
+	})
+}
+
+func requestScopeFromContext(ctx context.Context) *requestScope {
+	scope, _ := ctx.Value(requestScopeKey{}).(*requestScope)
+	return scope
+}
+
+// recordRateLimit records the requests left in the rate limit window after a response.
+func (s *requestScope) recordRateLimit(ctx context.Context, endpoint string, status int, header http.Header) {
+	if s == nil || s.metrics == nil {
+		return
+	}
+	if remaining, err := strconv.ParseInt(header.Get(headerRateRemaining), 10, 64); err == nil {
+		s.metrics.rateLimitRemaining.Record(ctx, remaining, s.tags(endpoint, status))
+	}
+}
+
+// recordRequest records a request with the status of its last response, 0 when it got none.
+func (s *requestScope) recordRequest(ctx context.Context, endpoint string, status int, elapsed time.Duration) {
+	if s == nil || s.metrics == nil {
+		return
+	}
+	tags := s.tags(endpoint, status)
+	s.metrics.requests.Add(ctx, 1, tags)
+	s.metrics.latency.Record(ctx, elapsed.Milliseconds(), tags)
+}
+
+func (s *requestScope) tags(endpoint string, status int) map[string]string {
+	rv := map[string]string{
+		"endpoint":      endpoint,
+		"resource_type": s.resourceType,
+		"organization":  s.organization,
+		"status":        "error",
+	}
+	if status != 0 {
+		rv["status"] = strconv.Itoa(status)
+	}
+	return rv
+}
+
+type callEndpointKey struct{}
+
+// callEndpoint is the endpoint of the last request sent by a Call, set by the tracing
+// transport as the method and the endpointPath of the request.
+type callEndpoint struct {
+	atomic.Value
+}
+
+func withCallEndpoint(ctx context.Context, endpoint *callEndpoint) context.Context {
+	return context.WithValue(ctx, callEndpointKey{}, endpoint)
+}
+
+// Load returns the endpoint, "unknown" when the call sent no request.
+func (e *callEndpoint) Load() string {
+	if endpoint, ok := e.Value.Load().(string); ok {
+		return endpoint
+	}
+	return "unknown"
+}
+
+// apiIDPattern matches the IDs of API objects, such as ws-CZcmD7eagjhyX0vN or team-6p5jTwJQXwqZBncC.
+var apiIDPattern = regexp.MustCompile(`^[a-z]+-[A-Za-z0-9]{10,}$`)
+
+// endpointPath replaces the organization name and object IDs of an API request path with
+// placeholders, so that requests to the same endpoint are traced under the same name.
+func endpointPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		switch {
+		case i > 0 && segments[i-1] == "organizations":
+			segments[i] = organizationPlaceholder
+		case apiIDPattern.MatchString(segment):
+			segments[i] = idPlaceholder
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// tracingTransport traces every request go-tfe sends.
+type tracingTransport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func newTracingTransport(base http.RoundTripper) *tracingTransport {
+	return &tracingTransport{
+		base:   base,
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := endpointPath(req.URL.Path)
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("tfe.endpoint", path),
+	}
+	if scope := requestScopeFromContext(req.Context()); scope != nil {
+		attributes = append(attributes,
+			attribute.String("baton.resource_type", scope.resourceType),
+			attribute.String("tfe.organization", scope.organization),
+		)
+	}
+	if endpoint, ok := req.Context().Value(callEndpointKey{}).(*callEndpoint); ok {
+		endpoint.Store(req.Method + " " + path)
+	}
+	ctx, span := t.tracer.Start(req.Context(), "tfe "+req.Method+" "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	if remaining, err := strconv.ParseInt(resp.Header.Get(headerRateRemaining), 10, 64); err == nil {
+		span.SetAttributes(attribute.Int64("tfe.rate_limit.remaining", remaining))
+	}
+	return resp, nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/metrics"
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestEndpointPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/v2/organizations/acme/workspaces":                   "/api/v2/organizations/:organization/workspaces",
+		"/api/v2/teams/team-6p5jTwJQXwqZBncC/relationships/users": "/api/v2/teams/:id/relationships/users",
+		"/api/v2/workspaces/ws-CZcmD7eagjhyX0vN/actions/lock":     "/api/v2/workspaces/:id/actions/lock",
+		"/api/v2/organizations/acme":                              "/api/v2/organizations/:organization",
+	} {
+		if got := endpointPath(path); got != want {
+			t.Errorf("endpointPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+type recordedValue struct {
+	name  string
+	value int64
+	tags  map[string]string
+}
+
+// recordingHandler is a metrics.Handler keeping every recorded value.
+type recordingHandler struct {
+	m      sync.Mutex
+	values []recordedValue
+}
+
+type recordingInstrument struct {
+	handler *recordingHandler
+	name    string
+}
+
+func (r *recordingHandler) record(name string, value int64, tags map[string]string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.values = append(r.values, recordedValue{name: name, value: value, tags: tags})
+}
+
+func (r *recordingHandler) Int64Counter(name string, _ string, _ metrics.Unit) metrics.Int64Counter {
+	return &recordingInstrument{handler: r, name: name}
+}
+
+func (r *recordingHandler) Int64Gauge(name string, _ string, _ metrics.Unit) metrics.Int64Gauge {
+	return &recordingInstrument{handler: r, name: name}
+}
+
+func (r *recordingHandler) Int64Histogram(name string, _ string, _ metrics.Unit) metrics.Int64Histogram {
+	return &recordingInstrument{handler: r, name: name}
+}
+
+func (r *recordingHandler) WithTags(map[string]string) metrics.Handler {
+	return r
+}
+
+func (i *recordingInstrument) Add(_ context.Context, value int64, tags map[string]string) {
+	i.handler.record(i.name, value, tags)
+}
+
+func (i *recordingInstrument) Observe(_ context.Context, value int64, tags map[string]string) {
+	i.handler.record(i.name, value, tags)
+}
+
+func (i *recordingInstrument) Record(_ context.Context, value int64, tags map[string]string) {
+	i.handler.record(i.name, value, tags)
+}
+
+func TestCallRecordsMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Header().Set(headerRateRemaining, "27")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors": [{"status": "403", "title": "forbidden"}]}`))
+	}))
+	defer server.Close()
+
+	handler := &recordingHandler{}
+	c, err := New("token", server.URL, WithMetricsHandler(handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a team's members are read without the organization in the path.
+	ctx := c.WithRequestScope(context.Background(), "team", "acme")
+	_, _ = Call(ctx, func(ctx context.Context) (*tfe.Team, error) {
+		return c.Teams.Read(ctx, "team-6p5jTwJQXwqZBncC")
+	})
+	// requests made outside a scope are not recorded.
+	_, _ = Call(context.Background(), func(ctx context.Context) (*tfe.Team, error) {
+		return c.Teams.Read(ctx, "team-6p5jTwJQXwqZBncC")
+	})
+
+	seen := map[string][]recordedValue{}
+	for _, v := range handler.values {
+		seen[v.name] = append(seen[v.name], v)
+	}
+	requests := seen[requestCounterName]
+	if len(requests) != 1 || requests[0].value != 1 {
+		t.Fatalf("expected one request to be counted, got %+v", handler.values)
+	}
+	want := map[string]string{
+		"endpoint":      "GET /api/v2/teams/:id",
+		"resource_type": "team",
+		"organization":  "acme",
+		"status":        "403",
+	}
+	if !maps.Equal(requests[0].tags, want) {
+		t.Errorf("expected tags %v, got %v", want, requests[0].tags)
+	}
+	if len(seen[requestLatencyName]) != 1 {
+		t.Errorf("expected the latency to be recorded once, got %+v", seen[requestLatencyName])
+	}
+	if remaining := seen[rateLimitRemainingName]; len(remaining) != 1 || remaining[0].value != 27 {
+		t.Errorf("expected 27 requests remaining, got %+v", remaining)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportConfig configures how the client reaches self-hosted Terraform Enterprise
+// instances behind an internal CA, mutual TLS or a proxy.
+type TransportConfig struct {
+	// CABundleFile is a PEM file of CA certificates trusted in addition to the system ones.
+	CABundleFile string
+	// ClientCertFile and ClientKeyFile are the PEM certificate and key presented to the server.
+	ClientCertFile string
+	ClientKeyFile  string
+	// ProxyURL replaces the proxy from the HTTPS_PROXY environment variable.
+	ProxyURL string
+	// InsecureSkipVerify disables server certificate verification.
+	InsecureSkipVerify bool
+}
+
+// Validate checks that the files and the proxy URL can be used.
+func (t *TransportConfig) Validate() error {
+	if t == nil {
+		return nil
+	}
+	_, err := t.transport()
+	return err
+}
+
+// WithTransportConfig builds the client's HTTP transport from config.
+func WithTransportConfig(config *TransportConfig) Option {
+	return func(c *Client) {
+		c.transportConfig = config
+	}
+}
+
+func (t *TransportConfig) transport() (*http.Transport, error) {
+	rv := http.DefaultTransport.(*http.Transport).Clone()
+	if t == nil {
+		return rv, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// #nosec G402 -- opted in with --insecure-skip-verify, logged as a warning.
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CABundleFile != "" {
+		pem, err := os.ReadFile(expandHome(t.CABundleFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("the CA bundle %s has no PEM certificates", t.CABundleFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case t.ClientCertFile != "" && t.ClientKeyFile != "":
+		cert, err := tls.LoadX509KeyPair(expandHome(t.ClientCertFile), expandHome(t.ClientKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case t.ClientCertFile != "" || t.ClientKeyFile != "":
+		return nil, fmt.Errorf("the client certificate and key must be set together")
+	}
+
+	if t.ProxyURL != "" {
+		proxy, err := url.Parse(t.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", t.ProxyURL)
+		}
+		rv.Proxy = http.ProxyURL(proxy)
+	}
+
+	rv.TLSClientConfig = tlsConfig
+	return rv, nil
+}
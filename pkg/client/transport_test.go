@@ -0,0 +1,66 @@
+package client
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransportConfigCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	get := func(config *TransportConfig) error {
+		transport, err := config.transport()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(&TransportConfig{}); err == nil {
+		t.Fatalf("expected the test server certificate to be untrusted")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(&TransportConfig{CABundleFile: bundle}); err != nil {
+		t.Errorf("expected the CA bundle to be trusted: %v", err)
+	}
+	if err := get(&TransportConfig{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("expected verification to be skipped: %v", err)
+	}
+}
+
+func TestTransportConfigValidate(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, config := range map[string]*TransportConfig{
+		"missing CA bundle":     {CABundleFile: "/nonexistent/ca.pem"},
+		"CA bundle without PEM": {CABundleFile: empty},
+		"cert without key":      {ClientCertFile: empty},
+		"invalid key pair":      {ClientCertFile: empty, ClientKeyFile: empty},
+		"invalid proxy":         {ProxyURL: "proxy.example.com:3128"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := (&TransportConfig{ProxyURL: "http://proxy.example.com:3128"}).Validate(); err != nil {
+		t.Errorf("expected a valid proxy: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Platform is the Terraform product the client talks to.
+type Platform string
+
+const (
+	PlatformHCPTerraform        Platform = "hcp_terraform"
+	PlatformTerraformEnterprise Platform = "terraform_enterprise"
+)
+
+// APIFeature is a part of the API that Terraform Enterprise releases gained over time.
+type APIFeature string
+
+const (
+	APIFeatureProjects          APIFeature = "projects"
+	APIFeatureTeamProjectAccess APIFeature = "team_project_access"
+	APIFeatureAgentPools        APIFeature = "agent_pools"
+)
+
+// minimumTFERelease is the first Terraform Enterprise release serving each feature.
+// HCP Terraform serves all of them.
+var minimumTFERelease = map[APIFeature]string{
+	APIFeatureAgentPools:        "v202109-1",
+	APIFeatureProjects:          "v202302-1",
+	APIFeatureTeamProjectAccess: "v202302-1",
+}
+
+// tfeVersionHeaderRelease is the first release that sends the X-TFE-Version header, older
+// releases are treated as the one just before it.
+const tfeVersionHeaderRelease = "v202208-3"
+
+// Platform returns whether the instance is HCP Terraform or Terraform Enterprise.
+func (c *Client) Platform() Platform {
+	// HCP Terraform was named Terraform Cloud before, and neither name is sent by all releases.
+	if c.IsCloud() || c.AppName() == "Terraform Cloud" {
+		return PlatformHCPTerraform
+	}
+	if c.AppName() == "" && c.RemoteTFEVersion() == "" {
+		switch c.BaseURL().Host {
+		case "app.terraform.io", "app.eu.terraform.io":
+			return PlatformHCPTerraform
+		}
+	}
+	return PlatformTerraformEnterprise
+}
+
+// Supports reports whether the instance serves the feature, according to the Terraform
+// Enterprise release it announces.
+func (c *Client) Supports(feature APIFeature) bool {
+	if c.Platform() == PlatformHCPTerraform {
+		return true
+	}
+	minimum, ok := minimumTFERelease[feature]
+	if !ok {
+		return true
+	}
+	version := c.RemoteTFEVersion()
+	if version == "" {
+		return compareTFERelease(minimum, tfeVersionHeaderRelease) < 0
+	}
+	return compareTFERelease(version, minimum) >= 0
+}
+
+// UnsupportedFeatures returns the features the instance does not serve, sorted.
+func (c *Client) UnsupportedFeatures() []APIFeature {
+	var rv []APIFeature
+	for feature := range minimumTFERelease {
+		if !c.Supports(feature) {
+			rv = append(rv, feature)
+		}
+	}
+	slices.Sort(rv)
+	return rv
+}
+
+// compareTFERelease compares Terraform Enterprise releases named "v<yyyymm>-<n>". Versions that
+// cannot be parsed, such as development builds, compare as the newest release.
+func compareTFERelease(a, b string) int {
+	ra, pa, okA := parseTFERelease(a)
+	rb, pb, okB := parseTFERelease(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	case ra != rb:
+		return ra - rb
+	default:
+		return pa - pb
+	}
+}
+
+func parseTFERelease(version string) (int, int, bool) {
+	release, patch, ok := strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	if !ok {
+		return 0, 0, false
+	}
+	r, err := strconv.Atoi(release)
+	if err != nil {
+		return 0, 0, false
+	}
+	p, err := strconv.Atoi(patch)
+	if err != nil {
+		return 0, 0, false
+	}
+	return r, p, true
+}
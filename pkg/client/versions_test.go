@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompareTFERelease(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v202302-1", "v202302-1", 0},
+		{"v202401-2", "v202302-1", 1},
+		{"v202302-1", "v202302-2", -1},
+		{"v202208-3", "v202109-1", 1},
+		{"dev", "v202302-1", 1},
+	}
+	for _, testCase := range testCases {
+		got := compareTFERelease(testCase.a, testCase.b)
+		if (got > 0) != (testCase.expected > 0) || (got < 0) != (testCase.expected < 0) {
+			t.Errorf("compare %s %s: expected %d, got %d", testCase.a, testCase.b, testCase.expected, got)
+		}
+	}
+}
+
+func TestSupports(t *testing.T) {
+	testCases := []struct {
+		message     string
+		headers     map[string]string
+		platform    Platform
+		unsupported []APIFeature
+	}{
+		{
+			message:  "HCP Terraform",
+			headers:  map[string]string{"TFP-AppName": "HCP Terraform", "TFP-API-Version": "2.6"},
+			platform: PlatformHCPTerraform,
+		},
+		{
+			message:  "recent Terraform Enterprise",
+			headers:  map[string]string{"TFP-AppName": "Terraform Enterprise", "X-TFE-Version": "v202401-1"},
+			platform: PlatformTerraformEnterprise,
+		},
+		{
+			message:     "Terraform Enterprise without projects",
+			headers:     map[string]string{"TFP-AppName": "Terraform Enterprise", "X-TFE-Version": "v202212-2"},
+			platform:    PlatformTerraformEnterprise,
+			unsupported: []APIFeature{APIFeatureProjects, APIFeatureTeamProjectAccess},
+		},
+		{
+			message:     "Terraform Enterprise without version header",
+			headers:     map[string]string{},
+			platform:    PlatformTerraformEnterprise,
+			unsupported: []APIFeature{APIFeatureProjects, APIFeatureTeamProjectAccess},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range testCase.headers {
+					w.Header().Set(name, value)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			c, err := New("token", server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if platform := c.Platform(); platform != testCase.platform {
+				t.Errorf("expected platform %s, got %s", testCase.platform, platform)
+			}
+			unsupported := c.UnsupportedFeatures()
+			if len(unsupported) != len(testCase.unsupported) {
+				t.Fatalf("expected unsupported features %v, got %v", testCase.unsupported, unsupported)
+			}
+			for i := range unsupported {
+				if unsupported[i] != testCase.unsupported[i] {
+					t.Errorf("expected unsupported features %v, got %v", testCase.unsupported, unsupported)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package connector
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type actionHandler func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error)
+
+type action struct {
+	schema  *v2.BatonActionSchema
+	handler actionHandler
+}
+
+type actionResult struct {
+	name     string
+	status   v2.BatonActionStatus
+	response *structpb.Struct
+}
+
+// actionManager implements connectorbuilder.CustomActionManager. Actions run
+// synchronously, their results are kept so they can be read back through
+// GetActionStatus.
+type actionManager struct {
+	m       *sync.Mutex
+	actions map[string]*action
+	results map[string]*actionResult
+}
+
+func (a *actionManager) register(schema *v2.BatonActionSchema, handler actionHandler) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.actions[schema.Name] = &action{
+		schema:  schema,
+		handler: handler,
+	}
+}
+
+func (a *actionManager) ListActionSchemas(ctx context.Context) ([]*v2.BatonActionSchema, annotations.Annotations, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	rv := make([]*v2.BatonActionSchema, 0, len(a.actions))
+	for _, act := range a.actions {
+		rv = append(rv, act.schema)
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Name < rv[j].Name
+	})
+	return rv, nil, nil
+}
+
+func (a *actionManager) GetActionSchema(ctx context.Context, name string) (*v2.BatonActionSchema, annotations.Annotations, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	act, ok := a.actions[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("baton-terraform-cloud: unknown action %s", name)
+	}
+	return act.schema, nil, nil
+}
+
+func (a *actionManager) InvokeAction(ctx context.Context, name string, args *structpb.Struct) (string, v2.BatonActionStatus, *structpb.Struct, annotations.Annotations, error) {
+	a.m.Lock()
+	act, ok := a.actions[name]
+	a.m.Unlock()
+	if !ok {
+		return "", v2.BatonActionStatus_BATON_ACTION_STATUS_UNSPECIFIED, nil, nil, fmt.Errorf("baton-terraform-cloud: unknown action %s", name)
+	}
+
+	id, err := newActionID()
+	if err != nil {
+		return "", v2.BatonActionStatus_BATON_ACTION_STATUS_UNSPECIFIED, nil, nil, err
+	}
+
+	status := v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE
+	resp, err := act.handler(ctx, args)
+	if err != nil {
+		status = v2.BatonActionStatus_BATON_ACTION_STATUS_FAILED
+		resp, _ = structpb.NewStruct(map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	a.m.Lock()
+	a.results[id] = &actionResult{
+		name:     name,
+		status:   status,
+		response: resp,
+	}
+	a.m.Unlock()
+
+	return id, status, resp, nil, nil
+}
+
+func (a *actionManager) GetActionStatus(ctx context.Context, id string) (v2.BatonActionStatus, string, *structpb.Struct, annotations.Annotations, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	result, ok := a.results[id]
+	if !ok {
+		return v2.BatonActionStatus_BATON_ACTION_STATUS_UNKNOWN, "", nil, nil, fmt.Errorf("baton-terraform-cloud: unknown action id %s", id)
+	}
+	return result.status, result.name, result.response, nil, nil
+}
+
+func newActionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("baton-terraform-cloud: failed to generate action id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newActionManager() *actionManager {
+	return &actionManager{
+		m:       &sync.Mutex{},
+		actions: make(map[string]*action),
+		results: make(map[string]*actionResult),
+	}
+}
+
+func stringActionField(name, displayName, description string, required bool) *config.Field {
+	return &config.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		IsRequired:  required,
+		Field: &config.Field_StringField{
+			StringField: &config.StringField{},
+		},
+	}
+}
+
+func getStringArg(args *structpb.Struct, name string) (string, error) {
+	value, ok := args.GetFields()[name]
+	if !ok || value.GetStringValue() == "" {
+		return "", fmt.Errorf("baton-terraform-cloud: missing required argument %s", name)
+	}
+	return value.GetStringValue(), nil
+}
+
+func getOptionalStringArg(args *structpb.Struct, name string) string {
+	return args.GetFields()[name].GetStringValue()
+}
+
+func boolActionField(name, displayName, description string) *config.Field {
+	return &config.Field{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Field: &config.Field_BoolField{
+			BoolField: &config.BoolField{},
+		},
+	}
+}
@@ -2,28 +2,85 @@ package connector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"sync"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/hashicorp/go-tfe"
+	"go.uber.org/zap"
 )
 
 type Connector struct {
 	client *client.Client
+
+	// instances is set when the connector syncs several instances. Their resource IDs are
+	// namespaced by instance and client is nil.
+	instances []*instance
+
+	// accountChoices holds the organizations and teams listed in the account creation schema.
+	// They are read when the connector is validated, so Metadata makes no API calls.
+	choicesMu      sync.RWMutex
+	accountChoices *accountChoices
+}
+
+// accountChoices are the organization and team names offered when an account is created.
+type accountChoices struct {
+	orgs  []string
+	teams []string
+}
+
+func newBuilders(c *client.Client) []connectorbuilder.ResourceSyncer {
+	builders := []connectorbuilder.ResourceSyncer{
+		newOrganizationBuilder(c),
+		newUserBuilder(c),
+		newProjectBuilder(c),
+		newWorkspaceBuilder(c),
+		newTeamBuilder(c),
+		newAgentTokenBuilder(c),
+	}
+	for i, builder := range builders {
+		builders[i] = newScopedSyncer(builder, c)
+	}
+	return builders
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *Connector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
-	return []connectorbuilder.ResourceSyncer{
-		newOrganizationBuilder(d.client),
-		newUserBuilder(d.client),
-		newProjectBuilder(d.client),
-		newWorkspaceBuilder(d.client),
-		newTeamBuilder(d.client),
-		newAgentTokenBuilder(d.client),
+	if d.instances == nil {
+		return newBuilders(d.client)
+	}
+
+	perInstance := make(map[string][]connectorbuilder.ResourceSyncer, len(d.instances))
+	for _, i := range d.instances {
+		perInstance[i.name] = newBuilders(i.client)
 	}
+
+	rv := []connectorbuilder.ResourceSyncer{&instanceBuilder{instances: d.instances}}
+	for index := range perInstance[d.instances[0].name] {
+		builders := make(map[string]connectorbuilder.ResourceSyncer, len(d.instances))
+		for name, instanceBuilders := range perInstance {
+			builders[name] = instanceBuilders[index]
+		}
+		rv = append(rv, newRoutingSyncer(builders, d.instances[0].name))
+	}
+	return rv
+}
+
+// targets returns the instances to talk to. A single instance connector has one unnamed
+// instance, whose organization names are not prefixed.
+func (d *Connector) targets() []*instance {
+	if d.instances == nil {
+		return []*instance{{client: d.client}}
+	}
+	return d.instances
 }
 
 // Asset takes an input AssetRef and attempts to fetch it using the connector's authenticated http client
@@ -35,54 +92,301 @@ func (d *Connector) Asset(ctx context.Context, asset *v2.AssetRef) (string, io.R
 // Metadata returns metadata about the connector.
 func (d *Connector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 	return &v2.ConnectorMetadata{
-		DisplayName: "My Baton Connector",
-		Description: "The template implementation of a baton connector.",
-		AccountCreationSchema: &v2.ConnectorAccountCreationSchema{
-			FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
-				"email": {
-					DisplayName: "Email",
-					Required:    true,
-					Description: "The email address of the user.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "Email",
-					Order:       1,
+		DisplayName:           "Terraform Cloud",
+		Description:           "Syncs organizations, users, teams, projects, workspaces and agent tokens from HCP Terraform and Terraform Enterprise.",
+		AccountCreationSchema: d.accountCreationSchema(),
+	}, nil
+}
+
+// accountCreationSchema describes the fields CreateAccount reads, listing the organizations and
+// teams read when the connector was last validated. Before that the schema has no choices.
+func (d *Connector) accountCreationSchema() *v2.ConnectorAccountCreationSchema {
+	orgDescription := "The name of the organization to which the user will belong."
+	teamDescription := "The names of the teams to which the user will belong. If not provided, the user joins the organization without any team."
+	orgField := &v2.ConnectorAccountCreationSchema_StringField{}
+	if d.instances != nil {
+		orgDescription = "The name of the organization to which the user will belong, prefixed with its instance as \"<instance>/<organization>\"."
+	}
+
+	d.choicesMu.RLock()
+	choices := d.accountChoices
+	d.choicesMu.RUnlock()
+	if choices != nil {
+		if len(choices.orgs) == 1 {
+			orgField.DefaultValue = &choices.orgs[0]
+		}
+		// the account creation schema has no choice fields, the names are listed in the descriptions.
+		if len(choices.orgs) > 0 {
+			orgDescription += " One of: " + strings.Join(choices.orgs, ", ") + "."
+		}
+		if len(choices.teams) > 0 {
+			teamDescription += " Available teams: " + strings.Join(choices.teams, ", ") + "."
+		}
+	}
+
+	return &v2.ConnectorAccountCreationSchema{
+		FieldMap: map[string]*v2.ConnectorAccountCreationSchema_Field{
+			"email": {
+				DisplayName: "Email",
+				Required:    true,
+				Description: "The email address of the user.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: &v2.ConnectorAccountCreationSchema_StringField{},
+				},
+				Placeholder: "Email",
+				Order:       1,
+			},
+			"organizationName": {
+				DisplayName: "Organization Name",
+				Required:    true,
+				Description: orgDescription,
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
+					StringField: orgField,
 				},
-				"organizationName": {
-					DisplayName: "Organization Name",
-					Required:    true,
-					Description: "The name of the organization to which the user will belong.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringField{
-						StringField: &v2.ConnectorAccountCreationSchema_StringField{},
-					},
-					Placeholder: "organizationName",
-					Order:       2,
+				Placeholder: "organizationName",
+				Order:       2,
+			},
+			"teamNames": {
+				DisplayName: "Team Names",
+				Required:    false,
+				Description: teamDescription,
+				Field: &v2.ConnectorAccountCreationSchema_Field_StringListField{
+					StringListField: &v2.ConnectorAccountCreationSchema_StringListField{},
 				},
-				"teamNames": {
-					DisplayName: "Team Names",
-					Required:    false,
-					Description: "The names of the teams to which the user will belong. if not provided, the user will be added to the default \"owners\" team.",
-					Field: &v2.ConnectorAccountCreationSchema_Field_StringListField{
-						StringListField: &v2.ConnectorAccountCreationSchema_StringListField{},
-					},
-					Placeholder: "Team Names",
-					Order:       3,
+				Placeholder: "Team Names",
+				Order:       3,
+			},
+			"addToOwners": {
+				DisplayName: "Add to owners",
+				Required:    false,
+				Description: "Add the user to the \"owners\" team, which has full control over the organization.",
+				Field: &v2.ConnectorAccountCreationSchema_Field_BoolField{
+					BoolField: &v2.ConnectorAccountCreationSchema_BoolField{},
 				},
+				Order: 4,
 			},
 		},
-	}, nil
+	}
+}
+
+// orgRef is an organization of one of the connector's instances.
+type orgRef struct {
+	instance *instance
+	name     string
+}
+
+// qualifiedName prefixes the organization name with its instance when there are several.
+func (o orgRef) qualifiedName() string {
+	if o.instance.name == "" {
+		return o.name
+	}
+	return o.instance.name + instanceSeparator + o.name
+}
+
+// loadAccountChoices reads the organizations and teams offered by the account creation schema.
+// Organizations or teams that cannot be read are left out.
+func (d *Connector) loadAccountChoices(ctx context.Context) {
+	l := ctxzap.Extract(ctx)
+
+	var orgs []orgRef
+	for _, target := range d.targets() {
+		targetOrgs, err := target.client.ListAllOrganizations(ctx)
+		if err != nil {
+			l.Warn("baton-terraform-cloud: failed to list organizations for the account creation schema",
+				zap.String("instance", target.name),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, org := range targetOrgs {
+			orgs = append(orgs, orgRef{instance: target, name: org.Name})
+		}
+	}
+
+	choices := &accountChoices{orgs: make([]string, 0, len(orgs))}
+	for _, org := range orgs {
+		choices.orgs = append(choices.orgs, org.qualifiedName())
+
+		teams, err := listAllTeams(ctx, org.instance.client, org.name)
+		if err != nil {
+			l.Warn("baton-terraform-cloud: failed to list teams for the account creation schema",
+				zap.String("organization", org.name),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, team := range teams {
+			name := team.Name
+			// team names are prefixed with their organization when more than one is in scope.
+			if len(orgs) > 1 {
+				name = org.qualifiedName() + "/" + team.Name
+			}
+			choices.teams = append(choices.teams, name)
+		}
+	}
+
+	d.choicesMu.Lock()
+	d.accountChoices = choices
+	d.choicesMu.Unlock()
+}
+
+// listAllTeams returns every team of the organization.
+func listAllTeams(ctx context.Context, c *client.Client, orgName string) ([]*tfe.Team, error) {
+	var rv []*tfe.Team
+	page := 0
+	for {
+		teams, err := client.Call(ctx, func(ctx context.Context) (*tfe.TeamList, error) {
+			return c.Teams.List(ctx, orgName, &tfe.TeamListOptions{
+				ListOptions: client.ListOptions(page),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, teams.Items...)
+		if teams.Pagination == nil || teams.NextPage == 0 {
+			return rv, nil
+		}
+		page = teams.NextPage
+	}
+}
+
+// registerActions registers the custom actions of one instance.
+func registerActions(am *actionManager, c *client.Client) {
+	users := newUserBuilder(c)
+	users.registerActions(am)
+	users.registerOffboardingAction(am)
+	newWorkspaceBuilder(c).registerActions(am)
+}
+
+// RegisterActionManager returns the custom actions supported by the connector.
+func (d *Connector) RegisterActionManager(ctx context.Context) (connectorbuilder.CustomActionManager, error) {
+	if d.instances == nil {
+		am := newActionManager()
+		registerActions(am, d.client)
+		return am, nil
+	}
+
+	perInstance := make(map[string]*actionManager, len(d.instances))
+	for _, i := range d.instances {
+		perInstance[i.name] = newActionManager()
+		registerActions(perInstance[i.name], i.client)
+	}
+	return newRoutingActionManager(perInstance, d.instances[0].name), nil
 }
 
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
-// to be sure that they are valid.
+// to be sure that they are valid. The syncer validates the connector before every sync, so the
+// caches of the previous sync are dropped here.
 func (d *Connector) Validate(ctx context.Context) (annotations.Annotations, error) {
+	missingProjects := map[string]int{}
+	targets := d.targets()
+	for _, target := range targets {
+		target.client.Cache.Clear()
+		target.client.SyncGaps.Clear()
+		missing, err := validateInstance(ctx, target.client)
+		if err != nil {
+			if target.name != "" {
+				return nil, fmt.Errorf("%s: %w", target.name, err)
+			}
+			return nil, err
+		}
+		for _, project := range missing {
+			missingProjects[project]++
+		}
+	}
+
+	// the project filter applies to every instance, a project has to exist in one of them.
+	var missing []string
+	for project, count := range missingProjects {
+		if count == len(targets) {
+			missing = append(missing, project)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("baton-terraform-cloud: configured projects not found: %s", strings.Join(missing, ", "))
+	}
+
+	d.loadAccountChoices(ctx)
 	return nil, nil
 }
 
+// validateInstance checks the token of one instance and returns the configured projects it
+// does not have.
+func validateInstance(ctx context.Context, c *client.Client) ([]string, error) {
+	l := ctxzap.Extract(ctx)
+
+	tokenType, account, err := c.TokenDetails(ctx)
+	if err != nil {
+		if errors.Is(err, tfe.ErrUnauthorized) {
+			return nil, fmt.Errorf("baton-terraform-cloud: the API token was rejected by %s, check the token and address", c.Address())
+		}
+		return nil, client.WrapError(err, "failed to read account details")
+	}
+	fields := []zap.Field{zap.String("address", c.Address()), zap.String("token_type", string(tokenType))}
+	if account != nil {
+		fields = append(fields, zap.String("account", account.Username))
+	}
+	l.Info("baton-terraform-cloud: validated API token", fields...)
+
+	l.Info("baton-terraform-cloud: detected instance version",
+		zap.String("address", c.Address()),
+		zap.String("platform", string(c.Platform())),
+		zap.String("api_version", c.RemoteAPIVersion()),
+		zap.String("tfe_version", c.RemoteTFEVersion()),
+	)
+	for _, feature := range c.UnsupportedFeatures() {
+		l.Warn("baton-terraform-cloud: feature not available on this Terraform Enterprise release, its resources and entitlements are skipped",
+			zap.String("address", c.Address()),
+			zap.String("tfe_version", c.RemoteTFEVersion()),
+			zap.String("feature", string(feature)),
+		)
+	}
+
+	orgs, err := c.ListAllOrganizations(ctx)
+	if err != nil {
+		return nil, client.WrapError(err, "failed to list organizations")
+	}
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("baton-terraform-cloud: the API token cannot read any organization in scope")
+	}
+
+	for _, org := range orgs {
+		entitlements, err := c.OrganizationEntitlements(ctx, org.Name)
+		if err != nil {
+			l.Warn("baton-terraform-cloud: failed to read organization entitlements, assuming all features are available",
+				zap.String("organization", org.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+		if !entitlements.Teams {
+			l.Warn("baton-terraform-cloud: teams are not available on this organization's plan, team sync is disabled",
+				zap.String("organization", org.Name),
+			)
+		}
+		if !entitlements.Agents {
+			l.Warn("baton-terraform-cloud: agents are not available on this organization's plan, agent token sync is disabled",
+				zap.String("organization", org.Name),
+			)
+		}
+	}
+
+	missing, err := c.MissingProjects(ctx)
+	if err != nil {
+		return nil, client.WrapError(err, "failed to validate project filter")
+	}
+	return missing, nil
+}
+
 // New returns a new instance of the connector.
-func New(ctx context.Context, token, address string) (*Connector, error) {
-	client, err := client.New(token, address)
+func New(ctx context.Context, token, address string, orgFilter *client.OrganizationFilter, workspaceFilter *client.WorkspaceFilter, opts ...client.Option) (*Connector, error) {
+	opts = append([]client.Option{
+		client.WithOrganizationFilter(orgFilter),
+		client.WithWorkspaceFilter(workspaceFilter),
+	}, opts...)
+	client, err := client.New(token, address, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -90,3 +394,28 @@ func New(ctx context.Context, token, address string) (*Connector, error) {
 		client: client,
 	}, nil
 }
+
+// NewMultiInstance returns a connector syncing several instances. The organization filter
+// applies to the instances that do not set their own.
+func NewMultiInstance(ctx context.Context, instances []*InstanceConfig, orgFilter *client.OrganizationFilter, workspaceFilter *client.WorkspaceFilter, opts ...client.Option) (*Connector, error) {
+	rv := &Connector{}
+	for _, config := range instances {
+		opts := append([]client.Option{
+			client.WithOrganizationFilter(config.organizationFilter(orgFilter)),
+			client.WithWorkspaceFilter(workspaceFilter),
+		}, opts...)
+		source, err := config.tokenSource()
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: instance %s: %w", config.Name, err)
+		}
+		if source != nil {
+			opts = append(opts, client.WithTokenSource(source))
+		}
+		c, err := client.New(config.Token, config.Address, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: instance %s: %w", config.Name, err)
+		}
+		rv.instances = append(rv.instances, &instance{name: config.Name, client: c})
+	}
+	return rv, nil
+}
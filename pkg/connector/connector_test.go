@@ -0,0 +1,107 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAccountCreationSchemaChoices(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/api/v2/organizations":
+			_, _ = w.Write([]byte(`{"data":[{"id":"acme","type":"organizations","attributes":{"name":"acme"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/api/v2/organizations/acme/teams":
+			if r.URL.Query().Get("page[number]") == "2" {
+				_, _ = w.Write([]byte(`{"data":[{"id":"team-2","type":"teams","attributes":{"name":"operators"}}],
+"meta":{"pagination":{"current-page":2,"prev-page":1,"total-pages":2}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[{"id":"team-1","type":"teams","attributes":{"name":"developers"}}],
+"meta":{"pagination":{"current-page":1,"next-page":2,"total-pages":2}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	d := &Connector{client: c}
+	ctx := context.Background()
+
+	teamDescription := func() string {
+		t.Helper()
+		metadata, err := d.Metadata(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return metadata.AccountCreationSchema.FieldMap["teamNames"].Description
+	}
+
+	if description := teamDescription(); strings.Contains(description, "Available teams") {
+		t.Errorf("expected no teams before the connector is validated, got %q", description)
+	}
+	if requests != 0 {
+		t.Fatalf("expected Metadata to make no requests, got %d", requests)
+	}
+
+	d.loadAccountChoices(ctx)
+	listed := requests
+	if description := teamDescription(); !strings.HasSuffix(description, "Available teams: developers, operators.") {
+		t.Errorf("expected the teams of every page, got %q", description)
+	}
+	if requests != listed {
+		t.Errorf("expected Metadata to make no requests, got %d", requests-listed)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		message       string
+		accountStatus int
+		organizations string
+		expectedError string
+	}{
+		{"user token", http.StatusOK, testOrganizations, ""},
+		{"organization token", http.StatusNotFound, testOrganizations, ""},
+		{"rejected token", http.StatusUnauthorized, testOrganizations, "the API token was rejected"},
+		{"no organization", http.StatusOK, testEmptyList, "cannot read any organization"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/api/v2/account/details":
+					w.WriteHeader(testCase.accountStatus)
+					if testCase.accountStatus == http.StatusOK {
+						_, _ = w.Write([]byte(`{"data":{"id":"user-1","type":"users","attributes":{"username":"jane"}}}`))
+					}
+				case r.URL.Path == "/api/v2/organizations":
+					_, _ = w.Write([]byte(testCase.organizations))
+				case strings.HasSuffix(r.URL.Path, "/entitlement-set"):
+					_, _ = w.Write([]byte(`{"data":{"id":"org-1","type":"entitlement-sets","attributes":{"teams":true,"agents":true}}}`))
+				case strings.HasSuffix(r.URL.Path, "/teams"):
+					_, _ = w.Write([]byte(testEmptyList))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+			d := &Connector{client: c}
+
+			_, err := d.Validate(context.Background())
+			if testCase.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected the token to be valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("expected an error containing %q, got %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
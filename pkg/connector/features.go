@@ -0,0 +1,46 @@
+package connector
+
+import (
+	"context"
+
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// featureEnabled reports whether a syncer depending on feature should run for the organization.
+// If the entitlements cannot be read the feature is assumed to be available, so that the
+// syncer surfaces the real API error instead of silently skipping data.
+func featureEnabled(ctx context.Context, c *client.Client, organization string, feature client.Feature) bool {
+	l := ctxzap.Extract(ctx)
+
+	enabled, err := c.FeatureEnabled(ctx, organization, feature)
+	if err != nil {
+		l.Debug("baton-terraform-cloud: failed to read organization entitlements",
+			zap.String("organization", organization),
+			zap.Error(err),
+		)
+		return true
+	}
+	if !enabled {
+		l.Info("baton-terraform-cloud: feature not available on the organization's plan, skipping",
+			zap.String("organization", organization),
+			zap.String("feature", string(feature)),
+		)
+	}
+	return enabled
+}
+
+// apiSupported reports whether a syncer depending on an API feature should run against the
+// client's instance, older Terraform Enterprise releases answer 404 for the endpoints.
+func apiSupported(ctx context.Context, c *client.Client, feature client.APIFeature) bool {
+	if c.Supports(feature) {
+		return true
+	}
+	ctxzap.Extract(ctx).Info("baton-terraform-cloud: feature not available on this Terraform Enterprise release, skipping",
+		zap.String("address", c.Address()),
+		zap.String("tfe_version", c.RemoteTFEVersion()),
+		zap.String("feature", string(feature)),
+	)
+	return false
+}
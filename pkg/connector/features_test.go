@@ -0,0 +1,74 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+func TestProjectsSkippedOnOldTerraformEnterprise(t *testing.T) {
+	c := newVersionedTestClient(t, http.Header{"X-Tfe-Version": {"v202212-1"}}, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	b := newProjectBuilder(c)
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	resources, _, _, err := b.List(context.Background(), orgID, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("expected no projects, got %d", len(resources))
+	}
+
+	project := &v2.Resource{Id: &v2.ResourceId{ResourceType: projectResourceType.Id, Resource: "prj-1"}, ParentResourceId: orgID}
+	entitlements, _, _, err := b.Entitlements(context.Background(), project, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entitlements) != 0 {
+		t.Errorf("expected no project entitlements, got %d", len(entitlements))
+	}
+}
+
+func TestSyncersGatedOnEntitlements(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			var listed []string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v2/organizations/acme/entitlement-set":
+					_, _ = fmt.Fprintf(w, `{"data":{"id":"org-acme","type":"entitlement-sets","attributes":{"teams":%[1]t,"agents":%[1]t}}}`, enabled)
+				case "/api/v2/organizations/acme/teams", "/api/v2/organizations/acme/agent-pools":
+					listed = append(listed, r.URL.Path)
+					_, _ = w.Write([]byte(testEmptyList))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+			orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+
+			if _, _, _, err := newTeamBuilder(c).List(context.Background(), orgID, &pagination.Token{}); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, _, err := newAgentTokenBuilder(c).List(context.Background(), orgID, &pagination.Token{}); err != nil {
+				t.Fatal(err)
+			}
+
+			var expected []string
+			if enabled {
+				expected = []string{"/api/v2/organizations/acme/teams", "/api/v2/organizations/acme/agent-pools"}
+			}
+			if !reflect.DeepEqual(listed, expected) {
+				t.Errorf("expected the requests %v, got %v", expected, listed)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+)
+
+// instanceSeparator joins the instance name and the upstream ID in namespaced resource IDs.
+// Organization names and API IDs never contain it.
+const instanceSeparator = "/"
+
+// InstanceConfig configures one HCP Terraform or Terraform Enterprise instance when the
+// connector syncs several of them.
+type InstanceConfig struct {
+	// Name namespaces the instance's resource IDs. Defaults to the host of the address.
+	Name    string `json:"name"`
+	Address string `json:"address"`
+
+	// Token, TokenFile and TerraformCredentialsFile are the ways to authenticate, exactly one
+	// of them is set. The files are read again when they change.
+	Token                    string `json:"token"`
+	TokenFile                string `json:"token_file"`
+	TerraformCredentialsFile string `json:"terraform_credentials_file"`
+
+	// OrganizationAllowlist and OrganizationDenylist override the connector wide organization
+	// filter for this instance.
+	OrganizationAllowlist []string `json:"organization_allowlist"`
+	OrganizationDenylist  []string `json:"organization_denylist"`
+}
+
+// ParseInstances parses a JSON list of instance configs, defaults their address and name and
+// validates them.
+func ParseInstances(raw string) ([]*InstanceConfig, error) {
+	var instances []*InstanceConfig
+	if err := json.Unmarshal([]byte(raw), &instances); err != nil {
+		return nil, fmt.Errorf("invalid instances: %w", err)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("invalid instances: at least one instance is required")
+	}
+
+	names := map[string]bool{}
+	for i, instance := range instances {
+		if instance == nil {
+			return nil, fmt.Errorf("invalid instances: instance %d is empty", i)
+		}
+		if instance.Address == "" {
+			instance.Address = client.DefaultAddress
+		}
+		if instance.Name == "" {
+			u, err := url.Parse(instance.Address)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid instances: instance %d has an invalid address %q", i, instance.Address)
+			}
+			instance.Name = u.Host
+		}
+		if strings.Contains(instance.Name, instanceSeparator) {
+			return nil, fmt.Errorf("invalid instances: instance name %q cannot contain %q", instance.Name, instanceSeparator)
+		}
+		if names[instance.Name] {
+			return nil, fmt.Errorf("invalid instances: duplicate instance name %q", instance.Name)
+		}
+		names[instance.Name] = true
+		switch countSet(instance.Token, instance.TokenFile, instance.TerraformCredentialsFile) {
+		case 0:
+			return nil, fmt.Errorf("invalid instances: instance %s has no token, token_file or terraform_credentials_file", instance.Name)
+		case 1:
+		default:
+			return nil, fmt.Errorf("invalid instances: instance %s sets more than one of token, token_file and terraform_credentials_file", instance.Name)
+		}
+		filter := &client.OrganizationFilter{Allow: instance.OrganizationAllowlist, Deny: instance.OrganizationDenylist}
+		if err := filter.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid instances: instance %s: %w", instance.Name, err)
+		}
+	}
+	return instances, nil
+}
+
+// organizationFilter returns the instance's organization filter, or the connector wide one
+// when the instance does not set any.
+func (i *InstanceConfig) organizationFilter(fallback *client.OrganizationFilter) *client.OrganizationFilter {
+	if len(i.OrganizationAllowlist) == 0 && len(i.OrganizationDenylist) == 0 {
+		return fallback
+	}
+	return &client.OrganizationFilter{
+		Allow: i.OrganizationAllowlist,
+		Deny:  i.OrganizationDenylist,
+	}
+}
+
+// tokenSource returns the token source of an instance authenticating with a file, nil when
+// it uses a static token.
+func (i *InstanceConfig) tokenSource() (client.TokenSource, error) {
+	switch {
+	case i.TokenFile != "":
+		return client.NewFileTokenSource(i.TokenFile), nil
+	case i.TerraformCredentialsFile != "":
+		return client.NewCLICredentialsTokenSource(i.TerraformCredentialsFile, i.Address)
+	default:
+		return nil, nil
+	}
+}
+
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// cutInstance splits "<instance>/<name>".
+func cutInstance(name string) (string, string, bool) {
+	return strings.Cut(name, instanceSeparator)
+}
+
+// instance is a configured instance and the client that talks to it.
+type instance struct {
+	name   string
+	client *client.Client
+}
+
+func namespaceID(instanceName string, id *v2.ResourceId) *v2.ResourceId {
+	if id == nil || id.ResourceType == instanceResourceType.Id {
+		return id
+	}
+	return &v2.ResourceId{
+		ResourceType:  id.ResourceType,
+		Resource:      instanceName + instanceSeparator + id.Resource,
+		BatonResource: id.BatonResource,
+	}
+}
+
+// splitID returns the instance of a namespaced resource ID and the upstream resource ID.
+func splitID(id *v2.ResourceId) (string, *v2.ResourceId, error) {
+	if id.ResourceType == instanceResourceType.Id {
+		return id.Resource, nil, nil
+	}
+	instanceName, resource, ok := strings.Cut(id.Resource, instanceSeparator)
+	if !ok {
+		return "", nil, fmt.Errorf("baton-terraform-cloud: resource ID %s is not namespaced by instance", id.Resource)
+	}
+	return instanceName, &v2.ResourceId{
+		ResourceType:  id.ResourceType,
+		Resource:      resource,
+		BatonResource: id.BatonResource,
+	}, nil
+}
+
+// namespaceEntitlementID namespaces the resource part of an entitlement ID built with
+// entitlement.NewEntitlementID, "<resource type>:<resource id>:<slug>".
+func namespaceEntitlementID(instanceName, entitlementID string) string {
+	resourceType, rest, ok := strings.Cut(entitlementID, ":")
+	if !ok {
+		return entitlementID
+	}
+	return resourceType + ":" + instanceName + instanceSeparator + rest
+}
+
+func stripEntitlementID(entitlementID string) string {
+	resourceType, rest, ok := strings.Cut(entitlementID, ":")
+	if !ok {
+		return entitlementID
+	}
+	_, rest, ok = strings.Cut(rest, instanceSeparator)
+	if !ok {
+		return entitlementID
+	}
+	return resourceType + ":" + rest
+}
+
+func namespaceResource(instanceName string, resource *v2.Resource) *v2.Resource {
+	if resource == nil {
+		return nil
+	}
+	rv := cloneResource(resource)
+	rv.Id = namespaceID(instanceName, resource.Id)
+	if resource.ParentResourceId == nil {
+		// organizations are the top level resources of an instance.
+		if resource.Id.GetResourceType() == organizationResourceType.Id {
+			rv.ParentResourceId = &v2.ResourceId{ResourceType: instanceResourceType.Id, Resource: instanceName}
+		}
+	} else {
+		rv.ParentResourceId = namespaceID(instanceName, resource.ParentResourceId)
+	}
+	return rv
+}
+
+func stripResource(resource *v2.Resource) (string, *v2.Resource, error) {
+	instanceName, id, err := splitID(resource.Id)
+	if err != nil {
+		return "", nil, err
+	}
+	rv := cloneResource(resource)
+	rv.Id = id
+	rv.ParentResourceId = nil
+	if parent := resource.ParentResourceId; parent != nil && parent.ResourceType != instanceResourceType.Id {
+		_, rv.ParentResourceId, err = splitID(parent)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return instanceName, rv, nil
+}
+
+func namespaceEntitlement(instanceName string, e *v2.Entitlement) *v2.Entitlement {
+	rv := cloneEntitlement(e)
+	rv.Resource = namespaceResource(instanceName, e.Resource)
+	rv.Id = namespaceEntitlementID(instanceName, e.Id)
+	return rv
+}
+
+func stripEntitlement(e *v2.Entitlement) (string, *v2.Entitlement, error) {
+	instanceName, resource, err := stripResource(e.Resource)
+	if err != nil {
+		return "", nil, err
+	}
+	rv := cloneEntitlement(e)
+	rv.Resource = resource
+	rv.Id = stripEntitlementID(e.Id)
+	return instanceName, rv, nil
+}
+
+func namespaceGrant(instanceName string, g *v2.Grant) (*v2.Grant, error) {
+	rv := cloneGrant(g)
+	rv.Entitlement = namespaceEntitlement(instanceName, g.Entitlement)
+	rv.Principal = namespaceResource(instanceName, g.Principal)
+	rv.Id = fmt.Sprintf("%s:%s:%s", rv.Entitlement.Id, rv.Principal.Id.ResourceType, rv.Principal.Id.Resource)
+
+	annos := annotations.Annotations(rv.Annotations)
+	expandable := &v2.GrantExpandable{}
+	ok, err := annos.Pick(expandable)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for i, entitlementID := range expandable.EntitlementIds {
+			expandable.EntitlementIds[i] = namespaceEntitlementID(instanceName, entitlementID)
+		}
+		annos.Update(expandable)
+		rv.Annotations = annos
+	}
+
+	// immutable grants name the team they come from by its resource ID.
+	immutable := &v2.GrantImmutable{}
+	ok, err = annos.Pick(immutable)
+	if err != nil {
+		return nil, err
+	}
+	if ok && immutable.SourceId != "" {
+		immutable.SourceId = instanceName + instanceSeparator + immutable.SourceId
+		annos.Update(immutable)
+		rv.Annotations = annos
+	}
+	return rv, nil
+}
+
+func stripGrant(g *v2.Grant) (string, *v2.Grant, error) {
+	instanceName, e, err := stripEntitlement(g.Entitlement)
+	if err != nil {
+		return "", nil, err
+	}
+	principalInstance, principal, err := stripResource(g.Principal)
+	if err != nil {
+		return "", nil, err
+	}
+	if principalInstance != instanceName {
+		return "", nil, fmt.Errorf("baton-terraform-cloud: principal %s belongs to another instance than %s", g.Principal.Id.Resource, g.Entitlement.Id)
+	}
+	rv := cloneGrant(g)
+	rv.Entitlement = e
+	rv.Principal = principal
+	rv.Id = fmt.Sprintf("%s:%s:%s", e.Id, principal.Id.ResourceType, principal.Id.Resource)
+	return instanceName, rv, nil
+}
+
+// instanceBuilder syncs the configured instances, the top level resources above organizations
+// when the connector syncs several instances.
+type instanceBuilder struct {
+	instances []*instance
+}
+
+func (o *instanceBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return instanceResourceType
+}
+
+func (o *instanceBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	if parentResourceID != nil {
+		return nil, "", nil, nil
+	}
+
+	rv := make([]*v2.Resource, 0, len(o.instances))
+	for _, instance := range o.instances {
+		resource, err := resourceSdk.NewResource(
+			instance.name,
+			instanceResourceType,
+			instance.name,
+			resourceSdk.WithDescription(instance.client.Address()),
+			resourceSdk.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: organizationResourceType.Id}),
+		)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create instance resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+	return rv, "", nil, nil
+}
+
+func (o *instanceBuilder) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (o *instanceBuilder) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
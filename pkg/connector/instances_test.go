@@ -0,0 +1,133 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseInstances(t *testing.T) {
+	instances, err := ParseInstances(`[{"token":"a"},{"address":"https://tfe.example.com","token":"b"},{"name":"eu","address":"https://tfe.example.com","token":"c"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"app.terraform.io", "tfe.example.com", "eu"}
+	for i, instance := range instances {
+		if instance.Name != expected[i] {
+			t.Errorf("expected instance %d to be named %s, got %s", i, expected[i], instance.Name)
+		}
+	}
+
+	for _, raw := range []string{
+		`[]`,
+		`[{"name":"a/b","token":"a"}]`,
+		`[{"token":"a"},{"token":"b"}]`,
+		`[{"name":"eu"}]`,
+		`[{"token":"a","terraform_credentials_file":"credentials.tfrc.json"}]`,
+		`[{"token":"a","organization_allowlist":["acme-["]}]`,
+	} {
+		if _, err := ParseInstances(raw); err == nil {
+			t.Errorf("expected %s to be invalid", raw)
+		}
+	}
+}
+
+func TestNamespaceGrantRoundTrip(t *testing.T) {
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	team, err := newTeamResource(&tfe.Team{ID: "team-1", Name: "developers"}, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := newWorkspaceResource(&tfe.Workspace{ID: "ws-1", Name: "network"}, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := grant.NewGrant(workspace, "admin", team.Id, grant.WithAnnotation(&v2.GrantExpandable{
+		EntitlementIds: []string{entitlement.NewEntitlementID(team, teamMembership)},
+	}))
+
+	namespaced, err := namespaceGrant("eu", g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespaced.Entitlement.Id != "workspace:eu/ws-1:admin" {
+		t.Errorf("unexpected entitlement ID %s", namespaced.Entitlement.Id)
+	}
+	if namespaced.Principal.Id.Resource != "eu/team-1" {
+		t.Errorf("unexpected principal ID %s", namespaced.Principal.Id.Resource)
+	}
+	if namespaced.Entitlement.Resource.ParentResourceId.Resource != "eu/acme" {
+		t.Errorf("unexpected parent ID %s", namespaced.Entitlement.Resource.ParentResourceId.Resource)
+	}
+	expandable := &v2.GrantExpandable{}
+	annos := annotations.Annotations(namespaced.Annotations)
+	if ok, err := annos.Pick(expandable); err != nil || !ok {
+		t.Fatalf("expected an expandable annotation: %v", err)
+	}
+	if expandable.EntitlementIds[0] != "team:eu/team-1:member" {
+		t.Errorf("unexpected expandable entitlement %s", expandable.EntitlementIds[0])
+	}
+
+	instanceName, stripped, err := stripGrant(namespaced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instanceName != "eu" {
+		t.Errorf("expected instance eu, got %s", instanceName)
+	}
+	if stripped.Id != g.Id || !proto.Equal(stripped.Entitlement.Resource.Id, g.Entitlement.Resource.Id) || !proto.Equal(stripped.Principal.Id, g.Principal.Id) {
+		t.Errorf("expected %v, got %v", g, stripped)
+	}
+}
+
+func TestRoutingSyncerListsOrganizationsBelowInstances(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations":
+			_, _ = w.Write([]byte(testOrganizations))
+		// the organizations' sync gap probes.
+		case "/api/v2/organizations/acme/entitlement-set", "/api/v2/organizations/other/entitlement-set":
+			_, _ = w.Write([]byte(`{"data":{"id":"acme","type":"entitlement-sets","attributes":{"teams":true,"agents":true}}}`))
+		case "/api/v2/organizations/acme/organization-memberships", "/api/v2/organizations/other/organization-memberships",
+			"/api/v2/organizations/acme/workspaces", "/api/v2/organizations/other/workspaces",
+			"/api/v2/organizations/acme/teams", "/api/v2/organizations/other/teams",
+			"/api/v2/organizations/acme/agent-pools", "/api/v2/organizations/other/agent-pools":
+			_, _ = w.Write([]byte(testEmptyList))
+		case "/api/v2/account/details":
+			_, _ = w.Write([]byte(`{"data":{"id":"user-1","type":"users","attributes":{"username":"jane"}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	connector := &Connector{instances: []*instance{{name: "eu", client: c}}}
+	syncers := connector.ResourceSyncers(context.Background())
+
+	var orgs []*v2.Resource
+	for _, syncer := range syncers {
+		if syncer.ResourceType(context.Background()).Id != organizationResourceType.Id {
+			continue
+		}
+		resources, _, _, err := syncer.List(context.Background(), &v2.ResourceId{ResourceType: instanceResourceType.Id, Resource: "eu"}, &pagination.Token{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		orgs = resources
+	}
+
+	if len(orgs) != 2 {
+		t.Fatalf("expected 2 organizations, got %d", len(orgs))
+	}
+	if orgs[0].Id.Resource != "eu/acme" || orgs[0].ParentResourceId.ResourceType != instanceResourceType.Id {
+		t.Errorf("expected organizations namespaced below the instance, got %v", orgs[0])
+	}
+}
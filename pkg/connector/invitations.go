@@ -0,0 +1,122 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	resendInvitationAction = "resend_invitation"
+	cancelInvitationAction = "cancel_invitation"
+)
+
+func invitationActionArguments() []*config.Field {
+	return []*config.Field{
+		stringActionField("organization", "Organization", "The name of the organization the user was invited to.", true),
+		stringActionField("email", "Email", "The email address the invitation was sent to.", true),
+	}
+}
+
+func (o *userBuilder) registerActions(am *actionManager) {
+	am.register(&v2.BatonActionSchema{
+		Name:        resendInvitationAction,
+		DisplayName: "Resend invitation",
+		Description: "Cancel a pending organization invitation and send a new one to the same email, keeping its teams.",
+		Arguments:   invitationActionArguments(),
+	}, o.resendInvitation)
+
+	am.register(&v2.BatonActionSchema{
+		Name:        cancelInvitationAction,
+		DisplayName: "Cancel invitation",
+		Description: "Cancel a pending organization invitation.",
+		Arguments:   invitationActionArguments(),
+	}, o.cancelInvitation)
+}
+
+// findInvitation returns the pending membership of email in orgName, with its teams included.
+func (o *userBuilder) findInvitation(ctx context.Context, args *structpb.Struct) (string, *tfe.OrganizationMembership, error) {
+	orgName, err := getStringArg(args, "organization")
+	if err != nil {
+		return "", nil, err
+	}
+	email, err := getStringArg(args, "email")
+	if err != nil {
+		return "", nil, err
+	}
+	if err := o.client.CheckOrganization(orgName); err != nil {
+		return "", nil, fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+
+	memberships, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembershipList, error) {
+		return o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
+			Emails:  []string{email},
+			Status:  tfe.OrganizationMembershipInvited,
+			Include: []tfe.OrgMembershipIncludeOpt{tfe.OrgMembershipTeam},
+		})
+	})
+	if err != nil {
+		return "", nil, client.WrapError(err, "failed to list organization memberships")
+	}
+
+	if len(memberships.Items) == 0 {
+		return "", nil, fmt.Errorf("baton-terraform-cloud: no pending invitation for %s in organization %s", email, orgName)
+	}
+
+	return orgName, memberships.Items[0], nil
+}
+
+func (o *userBuilder) cancelInvitation(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	_, invitation, err := o.findInvitation(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.OrganizationMemberships.Delete(ctx, invitation.ID)
+	})
+	if err != nil {
+		return nil, client.WrapError(err, "failed to cancel invitation")
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"success":                  true,
+		"organizationMembershipId": invitation.ID,
+	})
+}
+
+// resendInvitation re-creates the membership, since the API has no dedicated resend endpoint.
+func (o *userBuilder) resendInvitation(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	orgName, invitation, err := o.findInvitation(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.OrganizationMemberships.Delete(ctx, invitation.ID)
+	})
+	if err != nil {
+		return nil, client.WrapError(err, "failed to cancel invitation")
+	}
+
+	email := invitation.Email
+	membership, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembership, error) {
+		return o.client.OrganizationMemberships.Create(ctx, orgName, tfe.OrganizationMembershipCreateOptions{
+			Email: &email,
+			Teams: invitation.Teams,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: previous invitation was cancelled but a new one could not be sent: %w", err)
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"success":                  true,
+		"organizationMembershipId": membership.ID,
+	})
+}
@@ -0,0 +1,169 @@
+package connector
+
+import (
+	"context"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/hashicorp/go-tfe"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const offboardUserAction = "offboard_user"
+
+func (o *userBuilder) registerOffboardingAction(am *actionManager) {
+	am.register(&v2.BatonActionSchema{
+		Name:        offboardUserAction,
+		DisplayName: "Offboard user",
+		Description: "Remove a user from every visible organization and discard their queued runs. With a site admin token, also suspend them and revoke their user tokens.",
+		Arguments: []*config.Field{
+			stringActionField("email", "Email", "The email address of the user to offboard.", true),
+			boolActionField("suspend", "Suspend", "Suspend the account and revoke its user tokens. Requires a Terraform Enterprise site admin token."),
+		},
+	}, o.offboardUser)
+}
+
+// offboardUser removes the user from every in-scope organization. Each step is reported per
+// organization, a failure in one organization does not stop the others. The report is flagged
+// incomplete unless the account was suspended and its user tokens revoked.
+func (o *userBuilder) offboardUser(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	l := ctxzap.Extract(ctx)
+
+	email, err := getStringArg(args, "email")
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := o.client.ListAllOrganizations(ctx)
+	if err != nil {
+		return nil, client.WrapError(err, "failed to list organizations")
+	}
+
+	var user *tfe.User
+	organizations := map[string]interface{}{}
+	for _, org := range orgs {
+		report, member := o.offboardFromOrganization(ctx, org.Name, email)
+		organizations[org.Name] = report
+		if member != nil && user == nil {
+			user = member
+		}
+	}
+
+	rv := map[string]interface{}{
+		"email":         email,
+		"organizations": organizations,
+		// the account and its user tokens are left in place without suspend.
+		"incomplete": true,
+	}
+
+	if args.GetFields()["suspend"].GetBoolValue() {
+		if user == nil {
+			rv["account"] = map[string]interface{}{"error": "the user is not a member of any visible organization"}
+		} else {
+			account, complete := o.suspend(ctx, user.ID)
+			rv["account"] = account
+			// otherwise the user's tokens may still be live and the offboarding has to be finished by hand.
+			rv["incomplete"] = !complete
+		}
+	}
+
+	l.Info("baton-terraform-cloud: offboarded user", zap.String("email", email), zap.Int("organizations", len(orgs)))
+
+	return structpb.NewStruct(rv)
+}
+
+// offboardFromOrganization discards the user's queued runs and deletes their membership,
+// which removes them from the organization's teams. It returns the organization report and
+// the user when they were a member.
+func (o *userBuilder) offboardFromOrganization(ctx context.Context, orgName, email string) (map[string]interface{}, *tfe.User) {
+	memberships, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembershipList, error) {
+		return o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
+			Emails:  []string{email},
+			Include: []tfe.OrgMembershipIncludeOpt{tfe.OrgMembershipUser, tfe.OrgMembershipTeam},
+		})
+	})
+	if err != nil {
+		return map[string]interface{}{"error": client.WrapError(err, "failed to list organization memberships").Error()}, nil
+	}
+	if len(memberships.Items) == 0 {
+		return map[string]interface{}{"member": false}, nil
+	}
+
+	membership := memberships.Items[0]
+	report := map[string]interface{}{
+		"member":           true,
+		"membershipStatus": string(membership.Status),
+	}
+
+	teams := make([]interface{}, 0, len(membership.Teams))
+	for _, team := range membership.Teams {
+		teams = append(teams, team.ID)
+	}
+
+	// invited users have no account and cannot have created runs.
+	if membership.User != nil && membership.User.Username != "" {
+		runs, err := discardRunsCreatedBy(ctx, o.client, orgName, membership.User.Username, "User offboarded")
+		if err != nil {
+			report["runsError"] = err.Error()
+		} else {
+			report["runs"] = runs
+		}
+	}
+
+	if err := client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.OrganizationMemberships.Delete(ctx, membership.ID)
+	}); err != nil {
+		report["error"] = client.WrapError(err, "failed to delete organization membership").Error()
+		return report, membership.User
+	}
+	report["membershipDeleted"] = true
+	report["removedFromTeams"] = teams
+
+	return report, membership.User
+}
+
+// suspend uses the admin API to suspend the user and revoke their user tokens, the calls fail
+// unless the token belongs to a Terraform Enterprise site admin. The tokens are revoked even
+// when the suspension fails. It reports whether the user was suspended and no token is left.
+func (o *userBuilder) suspend(ctx context.Context, userID string) (map[string]interface{}, bool) {
+	report := map[string]interface{}{
+		"userId": userID,
+	}
+	complete := true
+
+	if _, err := client.Call(ctx, func(ctx context.Context) (*tfe.AdminUser, error) {
+		return o.client.Admin.Users.Suspend(ctx, userID)
+	}); err != nil {
+		report["suspendError"] = client.WrapError(err, "failed to suspend user").Error()
+		complete = false
+	} else {
+		report["suspended"] = true
+	}
+
+	tokens, err := client.Call(ctx, func(ctx context.Context) (*tfe.UserTokenList, error) {
+		return o.client.UserTokens.List(ctx, userID)
+	})
+	if err != nil {
+		report["tokensError"] = client.WrapError(err, "failed to list user tokens").Error()
+		return report, false
+	}
+	revoked := []interface{}{}
+	failed := map[string]interface{}{}
+	for _, token := range tokens.Items {
+		if err := client.CallErr(ctx, func(ctx context.Context) error {
+			return o.client.UserTokens.Delete(ctx, token.ID)
+		}); err != nil {
+			failed[token.ID] = client.WrapError(err, "failed to revoke user token").Error()
+			complete = false
+			continue
+		}
+		revoked = append(revoked, token.ID)
+	}
+	report["tokensRevoked"] = revoked
+	report["tokensFailed"] = failed
+
+	return report, complete
+}
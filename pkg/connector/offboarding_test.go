@@ -0,0 +1,169 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	testOrganizations = `{"data":[{"id":"acme","type":"organizations","attributes":{"name":"acme"}},
+{"id":"other","type":"organizations","attributes":{"name":"other"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`
+	testMemberships = `{"data":[{"id":"ou-1","type":"organization-memberships","attributes":{"status":"active","email":"jane@example.com"},
+"relationships":{"user":{"data":{"id":"user-1","type":"users"}},"teams":{"data":[{"id":"team-1","type":"teams"}]}}}],
+"included":[{"id":"user-1","type":"users","attributes":{"username":"jane"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`
+	testEmptyList = `{"data":[],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`
+	testRuns      = `{"data":[{"id":"run-1","type":"runs","attributes":{"actions":{"is-discardable":true}},
+"relationships":{"created-by":{"data":{"id":"user-1","type":"users"}}}},
+{"id":"run-2","type":"runs","attributes":{"actions":{"is-discardable":true}},
+"relationships":{"created-by":{"data":{"id":"user-2","type":"users"}}}}],
+"included":[{"id":"user-1","type":"users","attributes":{"username":"jane"}},{"id":"user-2","type":"users","attributes":{"username":"john"}}],
+"meta":{"pagination":{"current-page":1}}}`
+)
+
+func TestOffboardUserAction(t *testing.T) {
+	deleted := map[string]bool{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/organizations":
+			_, _ = w.Write([]byte(testOrganizations))
+		case "GET /api/v2/organizations/acme/organization-memberships":
+			_, _ = w.Write([]byte(testMemberships))
+		case "GET /api/v2/organizations/other/organization-memberships":
+			_, _ = w.Write([]byte(testEmptyList))
+		case "GET /api/v2/organizations/acme/runs":
+			_, _ = w.Write([]byte(testRuns))
+		case "POST /api/v2/runs/run-1/actions/discard":
+			deleted["run-1"] = true
+			w.WriteHeader(http.StatusAccepted)
+		case "DELETE /api/v2/organization-memberships/ou-1":
+			deleted["ou-1"] = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	am := newActionManager()
+	newUserBuilder(c).registerOffboardingAction(am)
+
+	args, err := structpb.NewStruct(map[string]interface{}{"email": "jane@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, status, resp, _, err := am.InvokeAction(context.Background(), offboardUserAction, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE {
+		t.Fatalf("expected a completed action, got %s: %v", status, resp.AsMap())
+	}
+	if !deleted["run-1"] || !deleted["ou-1"] {
+		t.Errorf("expected the run to be discarded and the membership deleted, got %v", deleted)
+	}
+
+	organizations := resp.AsMap()["organizations"].(map[string]interface{})
+	acme := organizations["acme"].(map[string]interface{})
+	if acme["membershipDeleted"] != true {
+		t.Errorf("expected the acme membership to be deleted, got %v", acme)
+	}
+	discarded := acme["runs"].(map[string]interface{})["discarded"].([]interface{})
+	if len(discarded) != 1 || discarded[0] != "run-1" {
+		t.Errorf("expected run-1 to be discarded, got %v", discarded)
+	}
+	if other := organizations["other"].(map[string]interface{}); other["member"] != false {
+		t.Errorf("expected no membership in other, got %v", other)
+	}
+	if _, ok := resp.AsMap()["account"]; ok {
+		t.Error("expected no account report without suspend")
+	}
+	if resp.AsMap()["incomplete"] != true {
+		t.Error("expected the offboarding to be incomplete without suspend")
+	}
+}
+
+func TestOffboardUserSuspends(t *testing.T) {
+	testCases := []struct {
+		message        string
+		deleteStatus   int
+		expectedFailed []string
+	}{
+		{"tokens revoked", http.StatusNoContent, nil},
+		{"token left", http.StatusForbidden, []string{"at-2"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			suspended := false
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method + " " + r.URL.Path {
+				case "GET /api/v2/organizations":
+					_, _ = w.Write([]byte(testOrganizations))
+				case "GET /api/v2/organizations/acme/organization-memberships":
+					_, _ = w.Write([]byte(testMemberships))
+				case "GET /api/v2/organizations/other/organization-memberships":
+					_, _ = w.Write([]byte(testEmptyList))
+				case "GET /api/v2/organizations/acme/runs":
+					_, _ = w.Write([]byte(testEmptyList))
+				case "DELETE /api/v2/organization-memberships/ou-1":
+					w.WriteHeader(http.StatusNoContent)
+				case "POST /api/v2/admin/users/user-1/actions/suspend":
+					suspended = true
+					_, _ = w.Write([]byte(`{"data":{"id":"user-1","type":"users","attributes":{"username":"jane","is-suspended":true}}}`))
+				case "GET /api/v2/users/user-1/authentication-tokens":
+					_, _ = w.Write([]byte(`{"data":[{"id":"at-1","type":"authentication-tokens"},{"id":"at-2","type":"authentication-tokens"}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+				case "DELETE /api/v2/authentication-tokens/at-1":
+					w.WriteHeader(http.StatusNoContent)
+				case "DELETE /api/v2/authentication-tokens/at-2":
+					w.WriteHeader(testCase.deleteStatus)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			am := newActionManager()
+			newUserBuilder(c).registerOffboardingAction(am)
+
+			args, err := structpb.NewStruct(map[string]interface{}{"email": "jane@example.com", "suspend": true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, status, resp, _, err := am.InvokeAction(context.Background(), offboardUserAction, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE {
+				t.Fatalf("expected a completed action, got %s: %v", status, resp.AsMap())
+			}
+			if !suspended {
+				t.Error("expected the user to be suspended")
+			}
+
+			account := resp.AsMap()["account"].(map[string]interface{})
+			if account["suspended"] != true {
+				t.Errorf("expected the account to be suspended, got %v", account)
+			}
+			failed := []string{}
+			for tokenID := range account["tokensFailed"].(map[string]interface{}) {
+				failed = append(failed, tokenID)
+			}
+			if len(failed) != len(testCase.expectedFailed) || (len(failed) > 0 && failed[0] != testCase.expectedFailed[0]) {
+				t.Errorf("expected the tokens %v to be left, got %v", testCase.expectedFailed, account)
+			}
+			if revoked := account["tokensRevoked"].([]interface{}); len(revoked) != 2-len(testCase.expectedFailed) {
+				t.Errorf("expected the other tokens to be revoked, got %v", revoked)
+			}
+			if resp.AsMap()["incomplete"] != (len(testCase.expectedFailed) > 0) {
+				t.Errorf("expected incomplete to be %v, got %v", len(testCase.expectedFailed) > 0, resp.AsMap())
+			}
+		})
+	}
+}
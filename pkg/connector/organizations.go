@@ -3,6 +3,7 @@ package connector
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -13,6 +14,7 @@ import (
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-terraform-cloud/pkg/client"
 	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
 )
 
 const orgMembership = "member"
@@ -25,11 +27,24 @@ func (o *organizationsBuilder) ResourceType(ctx context.Context) *v2.ResourceTyp
 	return organizationResourceType
 }
 
-func newOrganizationResource(org *tfe.Organization) (*v2.Resource, error) {
+// newOrganizationResource records the instance version and, through syncGaps, what the API
+// token cannot read in the organization.
+func newOrganizationResource(org *tfe.Organization, c *client.Client, syncGaps []string) (*v2.Resource, error) {
+	gaps := make([]interface{}, 0, len(syncGaps))
+	for _, gap := range syncGaps {
+		gaps = append(gaps, gap)
+	}
 	profile := map[string]interface{}{
+		"syncComplete":          len(syncGaps) == 0,
+		"syncGaps":              gaps,
+		"platform":              string(c.Platform()),
+		"apiVersion":            c.RemoteAPIVersion(),
+		"tfeVersion":            c.RemoteTFEVersion(),
 		"email":                 org.Email,
 		"costEstimationEnabled": org.CostEstimationEnabled,
 		"twoFactorConformant":   org.TwoFactorConformant,
+		"samlEnabled":           org.SAMLEnabled,
+		"ownersTeamSamlRoleId":  org.OwnersTeamSAMLRoleID,
 	}
 	return resourceSdk.NewGroupResource(
 		org.Name,
@@ -51,6 +66,8 @@ func newOrganizationResource(org *tfe.Organization) (*v2.Resource, error) {
 // List returns all the users from the database as resource objects.
 // Users include a UserTrait because they are the 'shape' of a standard user.
 func (o *organizationsBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	var page int
 	var err error
 	if pToken.Token != "" {
@@ -60,12 +77,14 @@ func (o *organizationsBuilder) List(ctx context.Context, parentResourceID *v2.Re
 		}
 	}
 
-	orgs, err := o.client.Organizations.List(ctx, &tfe.OrganizationListOptions{
-		ListOptions: client.ListOptions(page),
+	orgs, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationList, error) {
+		return o.client.Organizations.List(ctx, &tfe.OrganizationListOptions{
+			ListOptions: client.ListOptions(page),
+		})
 	})
 
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list organizations: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list organizations")
 	}
 
 	if len(orgs.Items) == 0 {
@@ -74,7 +93,10 @@ func (o *organizationsBuilder) List(ctx context.Context, parentResourceID *v2.Re
 
 	rv := make([]*v2.Resource, 0, len(orgs.Items))
 	for _, org := range orgs.Items {
-		resource, err := newOrganizationResource(org)
+		if o.client.CheckOrganization(org.Name) != nil {
+			continue
+		}
+		resource, err := newOrganizationResource(org, o.client, organizationSyncGaps(ctx, o.client, org))
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create organization resource: %w", err)
 		}
@@ -83,10 +105,10 @@ func (o *organizationsBuilder) List(ctx context.Context, parentResourceID *v2.Re
 
 	var nextPage string
 	if orgs.CurrentPage < orgs.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(orgs.NextPage)
 	}
 
-	return rv, nextPage, nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
 }
 
 func (o *organizationsBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -102,6 +124,8 @@ func (o *organizationsBuilder) Entitlements(ctx context.Context, resource *v2.Re
 }
 
 func (o *organizationsBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	var page int
 	var err error
 	if pToken.Token != "" {
@@ -111,14 +135,12 @@ func (o *organizationsBuilder) Grants(ctx context.Context, resource *v2.Resource
 		}
 	}
 
-	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organization-memberships
-	memberships, err := o.client.OrganizationMemberships.List(ctx, resource.Id.Resource, &tfe.OrganizationMembershipListOptions{
-		Include:     []tfe.OrgMembershipIncludeOpt{"user"},
-		ListOptions: client.ListOptions(page),
-	})
-
+	memberships, err := o.client.CachedOrganizationMemberships(ctx, resource.Id.Resource, page)
+	if annos, ok := skipDenied(ctx, o.client, err, "users", resource.Id.Resource); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list users: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list users")
 	}
 
 	if len(memberships.Items) == 0 {
@@ -140,10 +162,10 @@ func (o *organizationsBuilder) Grants(ctx context.Context, resource *v2.Resource
 
 	var nextPage string
 	if memberships.CurrentPage < memberships.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(memberships.NextPage)
 	}
 
-	return rv, nextPage, nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
 }
 
 func (o *organizationsBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
@@ -153,6 +175,9 @@ func (o *organizationsBuilder) Grant(ctx context.Context, principal *v2.Resource
 func (o *organizationsBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	entitlement := grant.Entitlement
 	orgName := entitlement.Resource.Id.Resource
+	if err := o.client.CheckOrganization(orgName); err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
 
 	userTrait, err := resourceSdk.GetUserTrait(grant.Principal)
 	if err != nil {
@@ -165,11 +190,13 @@ func (o *organizationsBuilder) Revoke(ctx context.Context, grant *v2.Grant) (ann
 		return nil, fmt.Errorf("baton-terraform-cloud: failed to get email from user trait")
 	}
 
-	orgMemberships, err := o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
-		Emails: []string{email},
+	orgMemberships, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembershipList, error) {
+		return o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
+			Emails: []string{email},
+		})
 	})
 	if err != nil {
-		return nil, fmt.Errorf("baton-terraform-cloud: failed to list organization memberships: %w", err)
+		return nil, client.WrapError(err, "failed to list organization memberships")
 	}
 
 	if len(orgMemberships.Items) == 0 {
@@ -177,9 +204,25 @@ func (o *organizationsBuilder) Revoke(ctx context.Context, grant *v2.Grant) (ann
 	}
 
 	orgMembershipId := orgMemberships.Items[0].ID
-	err = o.client.OrganizationMemberships.Delete(ctx, orgMembershipId)
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.OrganizationMemberships.Delete(ctx, orgMembershipId)
+	})
+	if client.ErrorCode(err) == codes.NotFound {
+		// a 404 may also mean the token cannot manage memberships, the grant is only gone if
+		// the membership is no longer listed for the email.
+		remaining, readErr := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembershipList, error) {
+			return o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
+				Emails: []string{email},
+			})
+		})
+		if readErr == nil && !slices.ContainsFunc(remaining.Items, func(m *tfe.OrganizationMembership) bool {
+			return m.ID == orgMembershipId
+		}) {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("baton-terraform-cloud: failed to remove user from organization: %w", err)
+		return nil, client.WrapError(err, "failed to remove user from organization")
 	}
 	return nil, nil
 }
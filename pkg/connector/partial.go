@@ -0,0 +1,95 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/hashicorp/go-tfe"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// skipDenied returns a warning annotation when err means the API token cannot read what,
+// so that the builder skips it instead of failing the sync. The skip is recorded in the
+// client's sync gaps for the organization profile. It returns false for other errors.
+func skipDenied(ctx context.Context, c *client.Client, err error, what, organization string) (annotations.Annotations, bool) {
+	if !client.IsAccessDenied(err) {
+		return nil, false
+	}
+
+	message := fmt.Sprintf("the API token cannot read %s of organization %s, they are skipped", what, organization)
+	ctxzap.Extract(ctx).Warn("baton-terraform-cloud: "+message, zap.Error(err))
+	c.SyncGaps.Record(organization, what+" not visible")
+
+	annos := annotations.Annotations{}
+	warning, structErr := structpb.NewStruct(map[string]interface{}{"warning": message})
+	if structErr == nil {
+		annos.Append(warning)
+	}
+	return annos, true
+}
+
+// sharedFirstPage reads a page of a listing. The first page is kept for the sync so the
+// organization's sync gap probe and the builder listing it read it once.
+func sharedFirstPage[T any](ctx context.Context, c *client.Client, key string, page int, call func(ctx context.Context) (T, error)) (T, error) {
+	if page > 1 {
+		return client.Call(ctx, call)
+	}
+	return client.CachedCall(c.Cache, key, func() (T, error) {
+		return client.Call(ctx, call)
+	})
+}
+
+// organizationSyncGaps returns the gaps recorded by skipDenied for the organization, so the
+// organization profile tells reviewers when the synced data is partial. The organization is
+// synced before its children, so the first page of each listing the builders read is read
+// here first, shared with the builders. Gaps found later, on further pages and in grants, are
+// reported by the warning annotations of the skipped calls.
+func organizationSyncGaps(ctx context.Context, c *client.Client, org *tfe.Organization) []string {
+	_, err := c.CachedOrganizationMemberships(ctx, org.Name, 0)
+	skipDenied(ctx, c, err, "users", org.Name)
+
+	projectIDs, err := c.ScopedProjectIDs(ctx, org.Name)
+	switch {
+	case err != nil:
+		skipDenied(ctx, c, err, "workspaces", org.Name)
+	case projectIDs == nil:
+		_, err = listWorkspaces(ctx, c, org.Name, "", 0)
+		skipDenied(ctx, c, err, "workspaces", org.Name)
+	case len(projectIDs) > 0:
+		_, err = listWorkspaces(ctx, c, org.Name, projectIDs[0], 0)
+		skipDenied(ctx, c, err, "workspaces", org.Name)
+	}
+
+	if enabled, err := c.FeatureEnabled(ctx, org.Name, client.FeatureTeams); err != nil || enabled {
+		_, err = listTeams(ctx, c, org.Name, 0)
+		if _, denied := skipDenied(ctx, c, err, "teams", org.Name); !denied && err == nil && !canSeeSecretTeams(ctx, c, org) {
+			c.SyncGaps.Record(org.Name, "secret teams not visible")
+		}
+	}
+
+	if c.Supports(client.APIFeatureAgentPools) {
+		if enabled, err := c.FeatureEnabled(ctx, org.Name, client.FeatureAgents); err != nil || enabled {
+			_, err = listAgentPools(ctx, c, org.Name, 0)
+			skipDenied(ctx, c, err, "agent pools", org.Name)
+		}
+	}
+
+	return c.SyncGaps.Organization(org.Name)
+}
+
+// canSeeSecretTeams reports whether the token sees the teams hidden from non members.
+// Organization tokens and owners see them, the organization permissions of other tokens
+// do not tell whether their team was granted access to secret teams, so they are assumed
+// not to see them.
+func canSeeSecretTeams(ctx context.Context, c *client.Client, org *tfe.Organization) bool {
+	tokenType, _, err := c.TokenDetails(ctx)
+	if err == nil && tokenType == client.TokenTypeOrganization {
+		return true
+	}
+	// only owners may update the organization settings.
+	return org.Permissions != nil && org.Permissions.CanUpdate
+}
@@ -0,0 +1,133 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestOrganizationSyncGaps(t *testing.T) {
+	requests := map[string]int{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/organization-memberships", "/api/v2/organizations/acme/workspaces":
+			_, _ = w.Write([]byte(testEmptyList))
+		case "/api/v2/organizations/acme/teams":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			// entitlements and agent pools.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	gaps := organizationSyncGaps(ctx, c, &tfe.Organization{Name: "acme"})
+	expected := []string{"teams not visible", "agent pools not visible"}
+	if !slices.Equal(gaps, expected) {
+		t.Errorf("expected gaps %v, got %v", expected, gaps)
+	}
+
+	// the builders read the first pages the probe read.
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	if _, _, _, err := newUserBuilder(c).List(ctx, orgID, &pagination.Token{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := newWorkspaceBuilder(c).List(ctx, orgID, &pagination.Token{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := newTeamBuilder(c).List(ctx, orgID, &pagination.Token{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		"/api/v2/organizations/acme/organization-memberships",
+		"/api/v2/organizations/acme/workspaces",
+		"/api/v2/organizations/acme/teams",
+	} {
+		if requests[path] != 1 {
+			t.Errorf("expected %s to be read once, got %d", path, requests[path])
+		}
+	}
+}
+
+func TestAgentTokensSkippedWhenForbidden(t *testing.T) {
+	poolRequests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/organization-memberships", "/api/v2/organizations/acme/workspaces":
+			_, _ = w.Write([]byte(testEmptyList))
+		case "/api/v2/organizations/acme/entitlement-set":
+			_, _ = w.Write([]byte(`{"data":{"id":"acme","type":"entitlement-sets","attributes":{"agents":true}}}`))
+		case "/api/v2/organizations/acme/agent-pools":
+			poolRequests++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":[{"status":"403","title":"forbidden"}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	b := newAgentTokenBuilder(c)
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	resources, next, _, err := b.List(context.Background(), orgID, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("expected the agent pools to be skipped, got %v", err)
+	}
+	if len(resources) != 0 || next != "" {
+		t.Errorf("expected no agent tokens and no next page, got %d and %q", len(resources), next)
+	}
+
+	gaps := organizationSyncGaps(context.Background(), c, &tfe.Organization{Name: "acme"})
+	if !slices.Contains(gaps, "agent pools not visible") {
+		t.Errorf("expected the organization to report the agent pools, got %v", gaps)
+	}
+	if poolRequests != 1 {
+		t.Errorf("expected the denied agent pools to be read once, got %d", poolRequests)
+	}
+}
+
+func TestSkippedGrantsRecordedAsGaps(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/team-projects":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":[{"status":"403","title":"forbidden"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	project, err := newProjectResource(&tfe.Project{ID: "prj-1", Name: "core"}, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, next, annos, err := newProjectBuilder(c).Grants(ctx, project, &pagination.Token{})
+	if err != nil {
+		t.Fatalf("expected the project team access to be skipped, got %v", err)
+	}
+	if len(grants) != 0 || next != "" {
+		t.Errorf("expected no grants and no next page, got %d and %q", len(grants), next)
+	}
+	warning := &structpb.Struct{}
+	if ok, err := annos.Pick(warning); err != nil || !ok {
+		t.Fatalf("expected a warning annotation, got %v", annos)
+	}
+	if !strings.Contains(warning.AsMap()["warning"].(string), "the team access of project prj-1") {
+		t.Errorf("expected the warning to name the project, got %v", warning.AsMap())
+	}
+
+	gaps := organizationSyncGaps(ctx, c, &tfe.Organization{Name: "acme"})
+	if !slices.Contains(gaps, "the team access of project prj-1 not visible") {
+		t.Errorf("expected the organization to report the skipped project team access, got %v", gaps)
+	}
+}
@@ -43,6 +43,8 @@ func newProjectResource(project *tfe.Project, parentID *v2.ResourceId) (*v2.Reso
 }
 
 func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
@@ -56,12 +58,21 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 		}
 	}
 
-	projects, err := o.client.Projects.List(ctx, parentResourceID.Resource, &tfe.ProjectListOptions{
-		ListOptions: client.ListOptions(page),
+	if !apiSupported(ctx, o.client, client.APIFeatureProjects) {
+		return nil, "", nil, nil
+	}
+
+	projects, err := client.Call(ctx, func(ctx context.Context) (*tfe.ProjectList, error) {
+		return o.client.Projects.List(ctx, parentResourceID.Resource, &tfe.ProjectListOptions{
+			ListOptions: client.ListOptions(page),
+		})
 	})
 
+	if annos, ok := skipDenied(ctx, o.client, err, "projects", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list projects: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list projects")
 	}
 
 	if len(projects.Items) == 0 {
@@ -70,6 +81,9 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 
 	rv := []*v2.Resource{}
 	for _, project := range projects.Items {
+		if !o.client.ProjectInScope(project) {
+			continue
+		}
 		resource, err := newProjectResource(project, parentResourceID)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create project resource: %w", err)
@@ -79,14 +93,18 @@ func (o *projectBuilder) List(ctx context.Context, parentResourceID *v2.Resource
 
 	var nextPage string
 	if projects.CurrentPage < projects.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(projects.NextPage)
 	}
 
-	return rv, nextPage, nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
 }
 
-// Entitlements always returns an empty slice for projects.
-func (o *projectBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+// Entitlements returns the project access levels, none when the instance cannot grant project access to teams.
+func (o *projectBuilder) Entitlements(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	if !apiSupported(ctx, o.client, client.APIFeatureTeamProjectAccess) {
+		return nil, "", nil, nil
+	}
+
 	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/project-team-access#project-team-access-levels
 	rv := make([]*v2.Entitlement, 0, len(permissions))
 	for _, permission := range permissions {
@@ -101,27 +119,25 @@ func (o *projectBuilder) Entitlements(_ context.Context, resource *v2.Resource,
 	return rv, "", nil, nil
 }
 
-// Grants always returns an empty slice for projects since they don't have any entitlements.
+// Grants grants each project access level to the teams holding it, expanded to the team's members.
 func (o *projectBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	var page int
-	var err error
-	if pToken.Token != "" {
-		page, err = strconv.Atoi(pToken.Token)
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
-		}
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
+	if !apiSupported(ctx, o.client, client.APIFeatureTeamProjectAccess) {
+		return nil, "", nil, nil
 	}
 
-	res, err := o.client.TeamProjectAccess.List(ctx, tfe.TeamProjectAccessListOptions{
-		ProjectID:   resource.Id.Resource,
-		ListOptions: client.ListOptions(page),
-	})
+	orgName := resource.ParentResourceId.GetResource()
+	projectAccess, err := o.client.ProjectTeamAccess(ctx, orgName, resource.Id.Resource)
+	if annos, ok := skipDenied(ctx, o.client, err, "the team access of project "+resource.Id.Resource, orgName); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list project team access: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list project team access")
 	}
 
 	rv := []*v2.Grant{}
-	for _, item := range res.Items {
+	for _, item := range projectAccess {
 		tr, err := newTeamResource(item.Team, resource.ParentResourceId)
 		if err != nil {
 			return nil, "", nil, err
@@ -147,12 +163,7 @@ func (o *projectBuilder) Grants(ctx context.Context, resource *v2.Resource, pTok
 		))
 	}
 
-	var nextPage string
-	if res.CurrentPage < res.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
-	}
-
-	return rv, nextPage, nil, nil
+	return rv, "", rateLimit.Annotations(), nil
 }
 
 func newProjectBuilder(client *client.Client) *projectBuilder {
@@ -0,0 +1,104 @@
+package connector
+
+import (
+	"context"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+)
+
+// resourceOrganization returns the organization a resource belongs to, the resource itself
+// for organizations and its parent for the resources listed below organizations.
+func resourceOrganization(resource *v2.Resource) string {
+	if resource.GetId().GetResourceType() == organizationResourceType.Id {
+		return resource.GetId().GetResource()
+	}
+	if parent := resource.GetParentResourceId(); parent.GetResourceType() == organizationResourceType.Id {
+		return parent.GetResource()
+	}
+	return ""
+}
+
+// scopedSyncer attributes the API requests of a builder to its resource type and to the
+// organization of the resources it syncs, in the request metrics and traces.
+type scopedSyncer struct {
+	builder connectorbuilder.ResourceSyncer
+	client  *client.Client
+	id      string
+}
+
+func (s *scopedSyncer) scope(ctx context.Context, organization string) context.Context {
+	return s.client.WithRequestScope(ctx, s.id, organization)
+}
+
+func (s *scopedSyncer) ResourceType(ctx context.Context) *v2.ResourceType {
+	return s.builder.ResourceType(ctx)
+}
+
+func (s *scopedSyncer) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	var organization string
+	if parentResourceID.GetResourceType() == organizationResourceType.Id {
+		organization = parentResourceID.GetResource()
+	}
+	return s.builder.List(s.scope(ctx, organization), parentResourceID, pToken)
+}
+
+func (s *scopedSyncer) Entitlements(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return s.builder.Entitlements(s.scope(ctx, resourceOrganization(resource)), resource, pToken)
+}
+
+func (s *scopedSyncer) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return s.builder.Grants(s.scope(ctx, resourceOrganization(resource)), resource, pToken)
+}
+
+type scopedProvisioner struct {
+	*scopedSyncer
+}
+
+func (s *scopedProvisioner) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	ctx = s.scope(ctx, resourceOrganization(entitlement.GetResource()))
+	return s.builder.(connectorbuilder.ResourceProvisioner).Grant(ctx, principal, entitlement)
+}
+
+func (s *scopedProvisioner) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	ctx = s.scope(ctx, resourceOrganization(grant.GetEntitlement().GetResource()))
+	return s.builder.(connectorbuilder.ResourceProvisioner).Revoke(ctx, grant)
+}
+
+type scopedAccountManager struct {
+	*scopedSyncer
+}
+
+func (s *scopedAccountManager) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	organization, _ := accountInfo.GetProfile().AsMap()["organizationName"].(string)
+	return s.builder.(connectorbuilder.AccountManager).CreateAccount(s.scope(ctx, organization), accountInfo, credentialOptions)
+}
+
+func (s *scopedAccountManager) CreateAccountCapabilityDetails(ctx context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	return s.builder.(connectorbuilder.AccountManager).CreateAccountCapabilityDetails(ctx)
+}
+
+// newScopedSyncer wraps a builder of c, keeping its provisioning capabilities.
+func newScopedSyncer(builder connectorbuilder.ResourceSyncer, c *client.Client) connectorbuilder.ResourceSyncer {
+	s := &scopedSyncer{
+		builder: builder,
+		client:  c,
+		id:      builder.ResourceType(context.Background()).Id,
+	}
+
+	switch builder.(type) {
+	case connectorbuilder.AccountManager:
+		return &scopedAccountManager{scopedSyncer: s}
+	case connectorbuilder.ResourceProvisioner:
+		return &scopedProvisioner{scopedSyncer: s}
+	default:
+		return s
+	}
+}
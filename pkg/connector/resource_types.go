@@ -12,6 +12,14 @@ var userResourceType = &v2.ResourceType{
 	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
 }
 
+// The instance resource type is the top level resource when the connector syncs several
+// HCP Terraform or Terraform Enterprise instances.
+var instanceResourceType = &v2.ResourceType{
+	Id:          "instance",
+	DisplayName: "Instance",
+	Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
+}
+
 var organizationResourceType = &v2.ResourceType{
 	Id:          "organization",
 	DisplayName: "Organization",
@@ -0,0 +1,248 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func cloneResource(r *v2.Resource) *v2.Resource {
+	return proto.Clone(r).(*v2.Resource)
+}
+
+func cloneEntitlement(e *v2.Entitlement) *v2.Entitlement {
+	return proto.Clone(e).(*v2.Entitlement)
+}
+
+func cloneGrant(g *v2.Grant) *v2.Grant {
+	return proto.Clone(g).(*v2.Grant)
+}
+
+// routingSyncer routes the calls for one resource type to the builder of the instance the
+// resource belongs to, namespacing the IDs it returns by instance.
+type routingSyncer struct {
+	resourceType *v2.ResourceType
+	builders     map[string]connectorbuilder.ResourceSyncer
+}
+
+func (r *routingSyncer) builder(instanceName string) (connectorbuilder.ResourceSyncer, error) {
+	b, ok := r.builders[instanceName]
+	if !ok {
+		return nil, fmt.Errorf("baton-terraform-cloud: unknown instance %s", instanceName)
+	}
+	return b, nil
+}
+
+func (r *routingSyncer) ResourceType(ctx context.Context) *v2.ResourceType {
+	return r.resourceType
+}
+
+func (r *routingSyncer) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// every resource is listed below its instance.
+	if parentResourceID == nil {
+		return nil, "", nil, nil
+	}
+
+	instanceName, parentID, err := splitID(parentResourceID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	resources, nextPage, annos, err := b.List(ctx, parentID, pToken)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: %w", instanceName, err)
+	}
+	rv := make([]*v2.Resource, 0, len(resources))
+	for _, resource := range resources {
+		rv = append(rv, namespaceResource(instanceName, resource))
+	}
+	return rv, nextPage, annos, nil
+}
+
+func (r *routingSyncer) Entitlements(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	instanceName, upstream, err := stripResource(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	entitlements, nextPage, annos, err := b.Entitlements(ctx, upstream, pToken)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: %w", instanceName, err)
+	}
+	rv := make([]*v2.Entitlement, 0, len(entitlements))
+	for _, e := range entitlements {
+		rv = append(rv, namespaceEntitlement(instanceName, e))
+	}
+	return rv, nextPage, annos, nil
+}
+
+func (r *routingSyncer) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	instanceName, upstream, err := stripResource(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	grants, nextPage, annos, err := b.Grants(ctx, upstream, pToken)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: %w", instanceName, err)
+	}
+	rv := make([]*v2.Grant, 0, len(grants))
+	for _, g := range grants {
+		namespaced, err := namespaceGrant(instanceName, g)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(rv, namespaced)
+	}
+	return rv, nextPage, annos, nil
+}
+
+// routingProvisioner routes provisioning for resource types whose builders support it.
+type routingProvisioner struct {
+	*routingSyncer
+}
+
+func (r *routingProvisioner) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	instanceName, upstreamEntitlement, err := stripEntitlement(entitlement)
+	if err != nil {
+		return nil, err
+	}
+	principalInstance, upstreamPrincipal, err := stripResource(principal)
+	if err != nil {
+		return nil, err
+	}
+	if principalInstance != instanceName {
+		return nil, fmt.Errorf("baton-terraform-cloud: principal %s belongs to another instance than %s", principal.Id.Resource, entitlement.Id)
+	}
+
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, err
+	}
+	return b.(connectorbuilder.ResourceProvisioner).Grant(ctx, upstreamPrincipal, upstreamEntitlement)
+}
+
+func (r *routingProvisioner) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	instanceName, upstream, err := stripGrant(grant)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, err
+	}
+	return b.(connectorbuilder.ResourceProvisioner).Revoke(ctx, upstream)
+}
+
+// routingAccountManager routes account creation by the instance prefix of the organization
+// name, "<instance>/<organization>".
+type routingAccountManager struct {
+	*routingSyncer
+	defaultInstance string
+}
+
+func (r *routingAccountManager) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	pMap := accountInfo.GetProfile().AsMap()
+	orgName, ok := pMap["organizationName"].(string)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: organizationName not found in profile")
+	}
+	instanceName, orgName, ok := cutInstance(orgName)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: organizationName must be prefixed with the instance name, %q", "<instance>/<organization>")
+	}
+	b, err := r.builder(instanceName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pMap["organizationName"] = orgName
+	if teamNames := parseTeamNames(pMap["teamNames"]); len(teamNames) > 0 {
+		upstreamTeams := make([]interface{}, 0, len(teamNames))
+		for _, teamName := range teamNames {
+			upstreamTeams = append(upstreamTeams, strings.TrimPrefix(teamName, instanceName+instanceSeparator))
+		}
+		pMap["teamNames"] = upstreamTeams
+	}
+	profile, err := structpb.NewStruct(pMap)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	upstream := proto.Clone(accountInfo).(*v2.AccountInfo)
+	upstream.Profile = profile
+
+	return b.(connectorbuilder.AccountManager).CreateAccount(ctx, upstream, credentialOptions)
+}
+
+func (r *routingAccountManager) CreateAccountCapabilityDetails(ctx context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	b, err := r.builder(r.defaultInstance)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.(connectorbuilder.AccountManager).CreateAccountCapabilityDetails(ctx)
+}
+
+// newRoutingSyncer wraps the per instance builders of one resource type, keeping the
+// provisioning capabilities of the builders.
+func newRoutingSyncer(builders map[string]connectorbuilder.ResourceSyncer, defaultInstance string) connectorbuilder.ResourceSyncer {
+	sample := builders[defaultInstance]
+	r := &routingSyncer{
+		resourceType: sample.ResourceType(context.Background()),
+		builders:     builders,
+	}
+
+	switch sample.(type) {
+	case connectorbuilder.AccountManager:
+		return &routingAccountManager{routingSyncer: r, defaultInstance: defaultInstance}
+	case connectorbuilder.ResourceProvisioner:
+		return &routingProvisioner{routingSyncer: r}
+	default:
+		return r
+	}
+}
+
+// newRoutingActionManager exposes the actions of every instance once, with an extra instance
+// argument choosing the instance that runs them.
+func newRoutingActionManager(perInstance map[string]*actionManager, defaultInstance string) *actionManager {
+	rv := newActionManager()
+	for name, act := range perInstance[defaultInstance].actions {
+		schema := proto.Clone(act.schema).(*v2.BatonActionSchema)
+		schema.Arguments = append(schema.Arguments, stringActionField("instance", "Instance", "The name of the instance to run the action on.", true))
+
+		rv.register(schema, func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+			instanceName, err := getStringArg(args, "instance")
+			if err != nil {
+				return nil, err
+			}
+			am, ok := perInstance[instanceName]
+			if !ok {
+				return nil, fmt.Errorf("baton-terraform-cloud: unknown instance %s", instanceName)
+			}
+			return am.actions[name].handler(ctx, args)
+		})
+	}
+	return rv
+}
@@ -11,8 +11,12 @@ import (
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-terraform-cloud/pkg/client"
 	"github.com/hashicorp/go-tfe"
+	"golang.org/x/sync/errgroup"
 )
 
+// agentPoolConcurrency bounds the number of agent pools whose tokens are listed at once.
+const agentPoolConcurrency = 5
+
 type agentTokenBuilder struct {
 	client *client.Client
 }
@@ -34,9 +38,21 @@ func newAgentTokenResource(agentToken *tfe.AgentToken, parentID *v2.ResourceId)
 	)
 }
 
+// listAgentPools reads a page of the organization's agent pools. The first page is shared with
+// the organization's sync gap probe.
+func listAgentPools(ctx context.Context, c *client.Client, orgName string, page int) (*tfe.AgentPoolList, error) {
+	return sharedFirstPage(ctx, c, "agent-pools-page:"+orgName, page, func(ctx context.Context) (*tfe.AgentPoolList, error) {
+		return c.AgentPools.List(ctx, orgName, &tfe.AgentPoolListOptions{
+			ListOptions: client.ListOptions(page),
+		})
+	})
+}
+
 // List returns all the agentTokens from the database as resource objects.
 // AgentTokens include a AgentTokenTrait because they are the 'shape' of a standard agentToken.
 func (o *agentTokenBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
@@ -50,25 +66,53 @@ func (o *agentTokenBuilder) List(ctx context.Context, parentResourceID *v2.Resou
 		}
 	}
 
-	agentPools, err := o.client.AgentPools.List(ctx, parentResourceID.Resource, &tfe.AgentPoolListOptions{
-		ListOptions: client.ListOptions(page),
-	})
+	if !apiSupported(ctx, o.client, client.APIFeatureAgentPools) || !featureEnabled(ctx, o.client, parentResourceID.Resource, client.FeatureAgents) {
+		return nil, "", nil, nil
+	}
 
+	agentPools, err := listAgentPools(ctx, o.client, parentResourceID.Resource, page)
+	if annos, ok := skipDenied(ctx, o.client, err, "agent pools", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list agent pools: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list agent pools")
 	}
 
 	if len(agentPools.Items) == 0 {
 		return nil, "", nil, nil
 	}
 
+	// the API only lists authentication tokens pool by pool, neither an organization wide
+	// listing nor an agent pool include exists for them. The token lists of a page of pools
+	// are fetched concurrently, go-tfe keeps the request rate within the API limits.
+	// pools whose tokens the API token cannot read are skipped.
+	poolTokens := make([][]*tfe.AgentToken, len(agentPools.Items))
+	poolWarnings := make([]annotations.Annotations, len(agentPools.Items))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(agentPoolConcurrency)
+	for i, pool := range agentPools.Items {
+		eg.Go(func() error {
+			agentTokens, err := client.Call(egCtx, func(ctx context.Context) (*tfe.AgentTokenList, error) {
+				return o.client.AgentTokens.List(ctx, pool.ID)
+			})
+			if annos, ok := skipDenied(egCtx, o.client, err, "the tokens of agent pool "+pool.ID, parentResourceID.Resource); ok {
+				poolWarnings[i] = annos
+				return nil
+			}
+			if err != nil {
+				return client.WrapError(err, "failed to list agentTokens")
+			}
+			poolTokens[i] = agentTokens.Items
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, "", nil, err
+	}
+
 	rv := []*v2.Resource{}
-	for _, pool := range agentPools.Items {
-		agentTokens, err := o.client.AgentTokens.List(ctx, pool.ID)
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list agentTokens: %w", err)
-		}
-		for _, agentToken := range agentTokens.Items {
+	for _, agentTokens := range poolTokens {
+		for _, agentToken := range agentTokens {
 			resource, err := newAgentTokenResource(agentToken, parentResourceID)
 			if err != nil {
 				return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create agentToken resource: %w", err)
@@ -80,10 +124,14 @@ func (o *agentTokenBuilder) List(ctx context.Context, parentResourceID *v2.Resou
 
 	var nextPage string
 	if agentPools.CurrentPage < agentPools.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(agentPools.NextPage)
 	}
 
-	return rv, nextPage, nil, nil
+	annos := rateLimit.Annotations()
+	for _, warnings := range poolWarnings {
+		annos = append(annos, warnings...)
+	}
+	return rv, nextPage, annos, nil
 }
 
 // Entitlements always returns an empty slice for secrets.
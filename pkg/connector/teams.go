@@ -2,9 +2,10 @@ package connector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
-	"sync"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -14,39 +15,147 @@ import (
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-terraform-cloud/pkg/client"
 	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
 )
 
 const teamMembership = "member"
 
 type teamBuilder struct {
-	client      *client.Client
-	m           *sync.Mutex
-	teamMembers map[string][]*tfe.User
+	client *client.Client
+}
+
+func teamMembersCacheKey(teamID string) string {
+	return "team-members:" + teamID
+}
+
+func teamMembersPageCacheKey(orgName string, page int) string {
+	return fmt.Sprintf("team-members-page:%s:%d", orgName, page)
+}
+
+func organizationTeamsCacheKey(orgName string) string {
+	return "teams:" + orgName
 }
 
 func (o *teamBuilder) cacheTeamMembers(teams *tfe.TeamList) {
-	o.m.Lock()
-	defer o.m.Unlock()
 	for _, team := range teams.Items {
-		o.teamMembers[team.ID] = team.Users
+		o.client.Cache.Set(teamMembersCacheKey(team.ID), team.Users)
 	}
 }
 
-func (o *teamBuilder) getTeamMembers(ctx context.Context, teamID string) ([]*tfe.User, error) {
-	o.m.Lock()
-	defer o.m.Unlock()
+// listTeams reads a page of the organization's teams with their users. The first page is
+// shared with the organization's sync gap probe.
+func listTeams(ctx context.Context, c *client.Client, orgName string, page int) (*tfe.TeamList, error) {
+	return sharedFirstPage(ctx, c, "teams-page:"+orgName, page, func(ctx context.Context) (*tfe.TeamList, error) {
+		return c.Teams.List(ctx, orgName, &tfe.TeamListOptions{
+			ListOptions: client.ListOptions(page),
+			Include: []tfe.TeamIncludeOpt{
+				"users",
+			},
+		})
+	})
+}
+
+// organizationTeams returns every team of the organization with its users and organization
+// access, loaded in one paginated pass and shared by the builders during a sync.
+func organizationTeams(ctx context.Context, c *client.Client, orgName string) ([]*tfe.Team, error) {
+	if teams, ok := client.CacheGet[[]*tfe.Team](c.Cache, organizationTeamsCacheKey(orgName)); ok {
+		return teams, nil
+	}
+
+	rv := []*tfe.Team{}
+	page := 0
+	for {
+		teams, err := listTeams(ctx, c, orgName, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams.Items {
+			c.Cache.Set(teamMembersCacheKey(team.ID), team.Users)
+		}
+		rv = append(rv, teams.Items...)
+		if teams.Pagination == nil || teams.NextPage == 0 {
+			break
+		}
+		page = teams.NextPage
+	}
+
+	c.Cache.Set(organizationTeamsCacheKey(orgName), rv)
+	return rv, nil
+}
 
-	users, ok := o.teamMembers[teamID]
+// teamUsers returns the users included on the team by List, used for the team's service
+// accounts. On a miss, the members of every team in
+// the organization are loaded in one paginated pass rather than reading teams one by one.
+func teamUsers(ctx context.Context, c *client.Client, orgName, teamID string) ([]*tfe.User, error) {
+	users, ok := client.CacheGet[[]*tfe.User](c.Cache, teamMembersCacheKey(teamID))
 	if ok {
 		return users, nil
 	}
 
-	team, err := o.client.Teams.Read(ctx, teamID)
+	teams, err := organizationTeams(ctx, c, orgName)
 	if err != nil {
 		return nil, err
 	}
+	for _, team := range teams {
+		if team.ID == teamID {
+			return team.Users, nil
+		}
+	}
+	return nil, nil
+}
+
+// teamMembersPage is a page of the organization's memberships indexed by team.
+type teamMembersPage struct {
+	// members are the user IDs of the members on the page, by team ID.
+	members map[string][]string
+	// nextPage is 0 on the last page.
+	nextPage int
+}
+
+// listTeamMembers reads a page of the organization's memberships, which include invited members
+// and list the teams of each member, and indexes it by team. The memberships are paged once per
+// organization for every team, sharing the pages with the users builder.
+func listTeamMembers(ctx context.Context, c *client.Client, orgName string, page int) (*teamMembersPage, error) {
+	key := teamMembersPageCacheKey(orgName, page)
+	if rv, ok := client.CacheGet[*teamMembersPage](c.Cache, key); ok {
+		return rv, nil
+	}
+
+	memberships, err := c.CachedOrganizationMemberships(ctx, orgName, page)
+	if err != nil {
+		return nil, err
+	}
+	rv := &teamMembersPage{members: make(map[string][]string)}
+	for _, membership := range memberships.Items {
+		if membership.User == nil {
+			continue
+		}
+		for _, team := range membership.Teams {
+			rv.members[team.ID] = append(rv.members[team.ID], membership.User.ID)
+		}
+	}
+	if memberships.Pagination != nil && memberships.CurrentPage < memberships.TotalPages {
+		rv.nextPage = memberships.NextPage
+	}
 
-	return team.Users, nil
+	c.Cache.Set(key, rv)
+	return rv, nil
+}
+
+// teamServiceAccountIDs returns the IDs of the team's service accounts. They have no
+// organization membership and come from the users included on the listed team.
+func teamServiceAccountIDs(ctx context.Context, c *client.Client, orgName, teamID string) ([]string, error) {
+	users, err := teamUsers(ctx, c, orgName, teamID)
+	if err != nil {
+		return nil, err
+	}
+	rv := []string{}
+	for _, user := range users {
+		if user.IsServiceAccount {
+			rv = append(rv, user.ID)
+		}
+	}
+	return rv, nil
 }
 
 func (o *teamBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -58,6 +167,7 @@ func newTeamResource(team *tfe.Team, parentID *v2.ResourceId) (*v2.Resource, err
 		"visibility": team.Visibility,
 		"userCount":  team.UserCount,
 		"isUnified":  team.IsUnified,
+		"ssoTeamId":  team.SSOTeamID,
 	}
 
 	return resourceSdk.NewGroupResource(
@@ -72,6 +182,8 @@ func newTeamResource(team *tfe.Team, parentID *v2.ResourceId) (*v2.Resource, err
 }
 
 func (o *teamBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
@@ -85,15 +197,16 @@ func (o *teamBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 		}
 	}
 
-	teams, err := o.client.Teams.List(ctx, parentResourceID.Resource, &tfe.TeamListOptions{
-		ListOptions: client.ListOptions(page),
-		Include: []tfe.TeamIncludeOpt{
-			"users",
-		},
-	})
+	if !featureEnabled(ctx, o.client, parentResourceID.Resource, client.FeatureTeams) {
+		return nil, "", nil, nil
+	}
 
+	teams, err := listTeams(ctx, o.client, parentResourceID.Resource, page)
+	if annos, ok := skipDenied(ctx, o.client, err, "teams", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list teams: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list teams")
 	}
 
 	if len(teams.Items) == 0 {
@@ -113,41 +226,110 @@ func (o *teamBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 
 	var nextPage string
 	if teams.CurrentPage < teams.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(teams.NextPage)
 	}
 
-	return rv, nextPage, nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
 }
 
 func (o *teamBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	description := fmt.Sprintf("Member of %s team", resource.DisplayName)
+	options := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Member of %s team", resource.DisplayName)),
+	}
+
+	// membership of SSO teams is set from the IdP at every login, so it cannot be provisioned here.
+	if ssoTeamID, _ := teamSSOID(resource); ssoTeamID != "" {
+		description = fmt.Sprintf("Member of %s team, managed by the identity provider group %s", resource.DisplayName, ssoTeamID)
+		options = append(options, entitlement.WithAnnotation(&v2.EntitlementImmutable{SourceId: ssoTeamID}))
+	}
+	options = append(options, entitlement.WithDescription(description))
+
 	return []*v2.Entitlement{
-		entitlement.NewAssignmentEntitlement(
-			resource,
-			teamMembership,
-			entitlement.WithGrantableTo(userResourceType),
-			entitlement.WithDescription(fmt.Sprintf("Member of %s team", resource.DisplayName)),
-			entitlement.WithDisplayName(fmt.Sprintf("Member of %s team", resource.DisplayName)),
-		),
+		entitlement.NewAssignmentEntitlement(resource, teamMembership, options...),
 	}, "", nil, nil
 }
 
-func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	users, err := o.getTeamMembers(ctx, resource.Id.Resource)
-	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to get team members: %w", err)
+// teamSSOID returns the SAML group the team is mapped to, as recorded on its profile. It returns
+// false when the resource carries no profile.
+func teamSSOID(resource *v2.Resource) (string, bool) {
+	groupTrait, err := resourceSdk.GetGroupTrait(resource)
+	if err != nil || groupTrait.GetProfile() == nil {
+		return "", false
 	}
+	ssoTeamID, _ := resourceSdk.GetProfileStringValue(groupTrait.GetProfile(), "ssoTeamId")
+	return ssoTeamID, true
+}
 
-	rv := []*v2.Grant{}
-	for _, user := range users {
-		// skipping non user accounts since there's no way to keep track of them
-		if user.IsServiceAccount {
-			continue
+// checkTeamNotSSOManaged refuses to change the membership of teams mapped to a SAML group, the
+// change would be reverted the next time the user signs in.
+func (o *teamBuilder) checkTeamNotSSOManaged(ctx context.Context, resource *v2.Resource) error {
+	ssoTeamID, ok := teamSSOID(resource)
+	if !ok {
+		// the resource may have been passed without its traits, read the team to be sure.
+		team, err := client.Call(ctx, func(ctx context.Context) (*tfe.Team, error) {
+			return o.client.Teams.Read(ctx, resource.Id.Resource)
+		})
+		if err != nil {
+			return client.WrapError(err, "failed to read team")
+		}
+		ssoTeamID = team.SSOTeamID
+	}
+	if ssoTeamID != "" {
+		return fmt.Errorf("baton-terraform-cloud: membership of team %s is managed by SSO, add the user to the identity provider group %s instead", resource.DisplayName, ssoTeamID)
+	}
+	return nil
+}
+
+// Grants grants the team to its members, a page of the organization's memberships at a time,
+// see listTeamMembers. The team's service accounts are granted once the memberships are exhausted.
+func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
+	teamID := resource.Id.Resource
+	orgName := resource.ParentResourceId.GetResource()
+
+	var memberIDs []string
+	var nextPage string
+	if pToken.Token == serviceAccountsPagePrefix {
+		var err error
+		memberIDs, err = teamServiceAccountIDs(ctx, o.client, orgName, teamID)
+		if annos, ok := skipDenied(ctx, o.client, err, "the service accounts of team "+teamID, orgName); ok {
+			return nil, "", annos, nil
 		}
-		principalID, err := resourceSdk.NewResourceID(userResourceType, user.ID)
 		if err != nil {
-			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create resource ID for user %v: %w", user.ID, err)
+			return nil, "", nil, client.WrapError(err, "failed to list team service accounts")
+		}
+	} else {
+		var page int
+		if pToken.Token != "" {
+			var err error
+			page, err = strconv.Atoi(pToken.Token)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
+			}
 		}
+		members, err := listTeamMembers(ctx, o.client, orgName, page)
+		if annos, ok := skipDenied(ctx, o.client, err, "the members of team "+teamID, orgName); ok {
+			return nil, serviceAccountsPagePrefix, annos, nil
+		}
+		if err != nil {
+			return nil, "", nil, client.WrapError(err, "failed to list team members")
+		}
+		memberIDs = members.members[teamID]
+		nextPage = serviceAccountsPagePrefix
+		if members.nextPage != 0 {
+			nextPage = strconv.Itoa(members.nextPage)
+		}
+	}
 
+	rv := make([]*v2.Grant, 0, len(memberIDs))
+	for _, userID := range memberIDs {
+		principalID, err := resourceSdk.NewResourceID(userResourceType, userID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create resource ID for user %v: %w", userID, err)
+		}
 		rv = append(rv, grant.NewGrant(
 			resource,
 			teamMembership,
@@ -155,17 +337,144 @@ func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 		))
 	}
 
-	return rv, "", nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
+}
+
+// checkTeamOrganization fails closed when the team's organization is unknown or out of scope.
+func (o *teamBuilder) checkTeamOrganization(team *v2.Resource) error {
+	if team.ParentResourceId == nil {
+		return fmt.Errorf("baton-terraform-cloud: team %s has no parent organization", team.Id.Resource)
+	}
+	if err := o.client.CheckOrganization(team.ParentResourceId.Resource); err != nil {
+		return fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+	return nil
 }
 
+// principalEmail returns the primary email of a user resource.
+func principalEmail(principal *v2.Resource) (string, error) {
+	userTrait, err := resourceSdk.GetUserTrait(principal)
+	if err != nil {
+		return "", fmt.Errorf("baton-terraform-cloud: failed to get user trait: %w", err)
+	}
+	for _, email := range userTrait.GetEmails() {
+		if email.GetAddress() != "" {
+			return email.GetAddress(), nil
+		}
+	}
+	if email, ok := resourceSdk.GetProfileStringValue(userTrait.GetProfile(), "email"); ok && email != "" {
+		return email, nil
+	}
+	return "", fmt.Errorf("baton-terraform-cloud: user %s has no email", principal.Id.Resource)
+}
+
+// findMembership returns the principal's membership in the organization, with its teams, or nil
+// if the principal is not a member. The membership ID recorded on the user profile is only
+// valid for the organization the user was synced under, other organizations are searched by email.
+func (o *teamBuilder) findMembership(ctx context.Context, principal *v2.Resource, orgName string) (*tfe.OrganizationMembership, error) {
+	if principal.ParentResourceId.GetResource() == orgName {
+		userTrait, err := resourceSdk.GetUserTrait(principal)
+		if err == nil {
+			membershipID, ok := resourceSdk.GetProfileStringValue(userTrait.GetProfile(), "organizationMembershipId")
+			if ok && membershipID != "" {
+				membership, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembership, error) {
+					return o.client.OrganizationMemberships.ReadWithOptions(ctx, membershipID, tfe.OrganizationMembershipReadOptions{
+						Include: []tfe.OrgMembershipIncludeOpt{tfe.OrgMembershipTeam},
+					})
+				})
+				if err == nil {
+					return membership, nil
+				}
+				if !errors.Is(err, tfe.ErrResourceNotFound) {
+					return nil, client.WrapError(err, "failed to read organization membership")
+				}
+			}
+		}
+	}
+
+	email, err := principalEmail(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembershipList, error) {
+		return o.client.OrganizationMemberships.List(ctx, orgName, &tfe.OrganizationMembershipListOptions{
+			Emails:  []string{email},
+			Include: []tfe.OrgMembershipIncludeOpt{tfe.OrgMembershipTeam},
+		})
+	})
+	if err != nil {
+		return nil, client.WrapError(err, "failed to list organization memberships")
+	}
+	if len(memberships.Items) == 0 {
+		return nil, nil
+	}
+	return memberships.Items[0], nil
+}
+
+func hasTeam(membership *tfe.OrganizationMembership, teamID string) bool {
+	return slices.ContainsFunc(membership.Teams, func(team *tfe.Team) bool {
+		return team.ID == teamID
+	})
+}
+
+func isServiceAccount(principal *v2.Resource) bool {
+	userTrait, err := resourceSdk.GetUserTrait(principal)
+	return err == nil && userTrait.GetAccountType() == v2.UserTrait_ACCOUNT_TYPE_SERVICE
+}
+
+// Grant adds the principal's organization membership to the team. Users who are not yet members
+// of the organization are invited straight into the team.
 func (o *teamBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
 	teamID := entitlement.Resource.Id.Resource
+	if err := o.checkTeamOrganization(entitlement.Resource); err != nil {
+		return nil, err
+	}
+	orgName := entitlement.Resource.ParentResourceId.Resource
+	if err := o.checkTeamNotSSOManaged(ctx, entitlement.Resource); err != nil {
+		return nil, err
+	}
+
+	if isServiceAccount(principal) {
+		return nil, fmt.Errorf("baton-terraform-cloud: service accounts are managed through team and organization tokens and cannot be added to teams")
+	}
+
+	membership, err := o.findMembership(ctx, principal, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	if membership == nil {
+		email, err := principalEmail(principal)
+		if err != nil {
+			return nil, err
+		}
+		_, err = client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembership, error) {
+			return o.client.OrganizationMemberships.Create(ctx, orgName, tfe.OrganizationMembershipCreateOptions{
+				Email: &email,
+				Teams: []*tfe.Team{{ID: teamID}},
+			})
+		})
+		if err != nil {
+			return nil, client.WrapError(err, "failed to invite user to organization")
+		}
+		return nil, nil
+	}
+
+	if hasTeam(membership, teamID) {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
 
-	err := o.client.TeamMembers.Add(ctx, teamID, tfe.TeamMemberAddOptions{
-		Usernames: []string{principal.DisplayName},
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.TeamMembers.Add(ctx, teamID, tfe.TeamMemberAddOptions{
+			OrganizationMembershipIDs: []string{membership.ID},
+		})
 	})
+	if client.ErrorCode(err) == codes.AlreadyExists {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("baton-terraform-cloud: failed to add user to team: %w", err)
+		return nil, client.WrapError(err, "failed to add user to team")
 	}
 
 	return nil, nil
@@ -174,20 +483,43 @@ func (o *teamBuilder) Grant(ctx context.Context, principal *v2.Resource, entitle
 func (o *teamBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	entitlement := grant.Entitlement
 	teamID := entitlement.Resource.Id.Resource
+	if err := o.checkTeamOrganization(entitlement.Resource); err != nil {
+		return nil, err
+	}
+	orgName := entitlement.Resource.ParentResourceId.Resource
+	if err := o.checkTeamNotSSOManaged(ctx, entitlement.Resource); err != nil {
+		return nil, err
+	}
+
+	membership, err := o.findMembership(ctx, grant.Principal, orgName)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || !hasTeam(membership, teamID) {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
 
-	err := o.client.TeamMembers.Remove(ctx, teamID, tfe.TeamMemberRemoveOptions{
-		Usernames: []string{grant.Principal.DisplayName},
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.TeamMembers.Remove(ctx, teamID, tfe.TeamMemberRemoveOptions{
+			OrganizationMembershipIDs: []string{membership.ID},
+		})
 	})
+	if client.ErrorCode(err) == codes.NotFound {
+		// a 404 may also mean the token cannot manage the team, the grant is only gone if
+		// the membership no longer lists the team.
+		membership, readErr := o.findMembership(ctx, grant.Principal, orgName)
+		if readErr == nil && (membership == nil || !hasTeam(membership, teamID)) {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("baton-terraform-cloud: failed to remove user from team: %w", err)
+		return nil, client.WrapError(err, "failed to remove user from team")
 	}
 	return nil, nil
 }
 
 func newTeamBuilder(client *client.Client) *teamBuilder {
 	return &teamBuilder{
-		client:      client,
-		m:           &sync.Mutex{},
-		teamMembers: make(map[string][]*tfe.User),
+		client: client,
 	}
 }
@@ -0,0 +1,366 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestTeamEntitlementsSSOManaged(t *testing.T) {
+	parentID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+
+	testCases := []struct {
+		message   string
+		ssoTeamID string
+		immutable bool
+	}{
+		{"manual team", "", false},
+		{"sso team", "okta-developers", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			resource, err := newTeamResource(&tfe.Team{ID: "team-1", Name: "developers", SSOTeamID: testCase.ssoTeamID}, parentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			builder := &teamBuilder{}
+			entitlements, _, _, err := builder.Entitlements(context.Background(), resource, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entitlements) != 1 {
+				t.Fatalf("expected 1 entitlement, got %d", len(entitlements))
+			}
+
+			immutable := &v2.EntitlementImmutable{}
+			annos := annotations.Annotations(entitlements[0].Annotations)
+			ok, err := annos.Pick(immutable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != testCase.immutable {
+				t.Errorf("expected immutable %v, got %v", testCase.immutable, ok)
+			}
+			if ok && immutable.SourceId != testCase.ssoTeamID {
+				t.Errorf("expected source %q, got %q", testCase.ssoTeamID, immutable.SourceId)
+			}
+		})
+	}
+}
+
+func TestCheckTeamNotSSOManaged(t *testing.T) {
+	resource, err := newTeamResource(&tfe.Team{ID: "team-1", Name: "developers", SSOTeamID: "okta-developers"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := &teamBuilder{}
+	if err := builder.checkTeamNotSSOManaged(context.Background(), resource); err == nil {
+		t.Error("expected an error for an SSO managed team")
+	}
+}
+
+const testTeams = `{"data":[
+{"id":"team-1","type":"teams","attributes":{"name":"developers"},"relationships":{"users":{"data":[{"id":"user-1","type":"users"}]}}},
+{"id":"team-2","type":"teams","attributes":{"name":"operators"},"relationships":{"users":{"data":[{"id":"user-2","type":"users"}]}}}],
+"included":[{"id":"user-1","type":"users","attributes":{"username":"jane"}},{"id":"user-2","type":"users","attributes":{"username":"joe"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`
+
+func TestTeamMembersReadAgainAfterEviction(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/organizations/acme/teams" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		_, _ = w.Write([]byte(testTeams))
+	})
+	// room for the teams of the organization and the members of one team only.
+	c.Cache = client.NewCache(client.CacheTTL, 2)
+
+	expectMembers := func(teamID, username string) {
+		t.Helper()
+		users, err := teamUsers(context.Background(), c, "acme", teamID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 || users[0].Username != username {
+			t.Errorf("expected %s to have member %s, got %v", teamID, username, users)
+		}
+	}
+
+	expectMembers("team-2", "joe")
+	// the members of team-1 were evicted by the teams of the organization, they are read
+	// from the cached teams.
+	if _, ok := c.Cache.Get(teamMembersCacheKey("team-1")); ok {
+		t.Fatal("expected the members of team-1 to be evicted")
+	}
+	expectMembers("team-1", "jane")
+	if requests != 1 {
+		t.Errorf("expected the cached teams to be used, got %d requests", requests)
+	}
+
+	// once the teams are evicted too, they are read again.
+	c.Cache.Set("other", 1)
+	c.Cache.Set("another", 2)
+	expectMembers("team-2", "joe")
+	if requests != 2 {
+		t.Errorf("expected the teams to be read again, got %d requests", requests)
+	}
+}
+
+func TestTeamGrants(t *testing.T) {
+	requests := map[string]int{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path+"?"+r.URL.Query().Get("page[number]")]++
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/organization-memberships":
+			if include := r.URL.Query().Get("include"); include != "user,teams" {
+				t.Errorf("expected the users and teams to be included, got %q", include)
+			}
+			if r.URL.Query().Get("page[number]") == "2" {
+				_, _ = w.Write([]byte(`{"data":[
+{"id":"ou-3","type":"organization-memberships","attributes":{"status":"invited"},
+"relationships":{"user":{"data":{"id":"user-3","type":"users"}},"teams":{"data":[{"id":"team-1","type":"teams"}]}}}],
+"meta":{"pagination":{"current-page":2,"prev-page":1,"total-pages":2}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[
+{"id":"ou-1","type":"organization-memberships","attributes":{"status":"active"},
+"relationships":{"user":{"data":{"id":"user-1","type":"users"}},"teams":{"data":[{"id":"team-1","type":"teams"},{"id":"team-2","type":"teams"}]}}},
+{"id":"ou-2","type":"organization-memberships","attributes":{"status":"active"},
+"relationships":{"user":{"data":{"id":"user-2","type":"users"}},"teams":{"data":[{"id":"team-2","type":"teams"}]}}}],
+"meta":{"pagination":{"current-page":1,"next-page":2,"total-pages":2}}}`))
+		case "/api/v2/organizations/acme/teams":
+			_, _ = w.Write([]byte(`{"data":[{"id":"team-1","type":"teams","attributes":{"name":"developers"},
+"relationships":{"users":{"data":[{"id":"user-1","type":"users"},{"id":"user-sa","type":"users"}]}}},
+{"id":"team-2","type":"teams","attributes":{"name":"operators"},"relationships":{"users":{"data":[]}}}],
+"included":[{"id":"user-1","type":"users","attributes":{"username":"jane"}},
+{"id":"user-sa","type":"users","attributes":{"username":"api-team_1","is-service-account":true}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	builder := newTeamBuilder(c)
+	parentID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+
+	grantedPrincipals := func(teamID string) ([]string, []string) {
+		t.Helper()
+		resource, err := newTeamResource(&tfe.Team{ID: teamID, Name: teamID}, parentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var principals, tokens []string
+		token := ""
+		for {
+			grants, next, _, err := builder.Grants(context.Background(), resource, &pagination.Token{Token: token})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, g := range grants {
+				principals = append(principals, g.Principal.Id.Resource)
+			}
+			if next == "" {
+				return principals, tokens
+			}
+			tokens = append(tokens, next)
+			token = next
+		}
+	}
+
+	principals, tokens := grantedPrincipals("team-1")
+	if !reflect.DeepEqual(principals, []string{"user-1", "user-3", "user-sa"}) {
+		t.Errorf("expected the active and invited members and the service account, got %v", principals)
+	}
+	if !reflect.DeepEqual(tokens, []string{"2", serviceAccountsPagePrefix}) {
+		t.Errorf("expected a page token per membership page, got %v", tokens)
+	}
+	if principals, _ := grantedPrincipals("team-2"); !reflect.DeepEqual(principals, []string{"user-1", "user-2"}) {
+		t.Errorf("expected the members of team-2, got %v", principals)
+	}
+
+	// the memberships are paged once for both teams.
+	for path, count := range requests {
+		if count != 1 {
+			t.Errorf("expected %s to be requested once, got %d", path, count)
+		}
+	}
+}
+
+const (
+	testMembershipInTeam = `{"data":{"id":"ou-1","type":"organization-memberships","attributes":{"status":"active","email":"jane@example.com"},
+"relationships":{"teams":{"data":[{"id":"team-1","type":"teams"}]}}}}`
+	testMembershipNoTeam = `{"data":{"id":"ou-1","type":"organization-memberships","attributes":{"status":"active","email":"jane@example.com"},
+"relationships":{"teams":{"data":[]}}}}`
+	testNotFound = `{"errors":[{"status":"404","title":"not found"}]}`
+)
+
+type testResponse struct {
+	status int
+	body   string
+}
+
+func TestTeamProvisioning(t *testing.T) {
+	const (
+		readMembership   = "GET /api/v2/organization-memberships/ou-1"
+		findByEmail      = "GET /api/v2/organizations/acme/organization-memberships"
+		invite           = "POST /api/v2/organizations/acme/organization-memberships"
+		addToTeam        = "POST /api/v2/teams/team-1/relationships/organization-memberships"
+		removeFromTeam   = "DELETE /api/v2/teams/team-1/relationships/organization-memberships"
+		invitedMember    = `{"data":{"id":"ou-2","type":"organization-memberships","attributes":{"status":"invited","email":"jane@example.com"}}}`
+		revokeGrant      = "revoke"
+		grantEntitlement = "grant"
+	)
+
+	testCases := []struct {
+		message     string
+		action      string
+		responses   map[string][]testResponse
+		expected    []string
+		annotation  string
+		expectError bool
+	}{
+		{
+			message:   "grant adds the membership to the team",
+			action:    grantEntitlement,
+			responses: map[string][]testResponse{readMembership: {{http.StatusOK, testMembershipNoTeam}}, addToTeam: {{http.StatusNoContent, ""}}},
+			expected:  []string{readMembership, addToTeam},
+		},
+		{
+			message:    "grant to a team member",
+			action:     grantEntitlement,
+			responses:  map[string][]testResponse{readMembership: {{http.StatusOK, testMembershipInTeam}}},
+			expected:   []string{readMembership},
+			annotation: "GrantAlreadyExists",
+		},
+		{
+			message: "grant invites a user who left the organization",
+			action:  grantEntitlement,
+			responses: map[string][]testResponse{
+				readMembership: {{http.StatusNotFound, testNotFound}},
+				findByEmail:    {{http.StatusOK, testEmptyList}},
+				invite:         {{http.StatusCreated, invitedMember}},
+			},
+			expected: []string{readMembership, findByEmail, invite},
+		},
+		{
+			message:   "revoke removes the membership from the team",
+			action:    revokeGrant,
+			responses: map[string][]testResponse{readMembership: {{http.StatusOK, testMembershipInTeam}}, removeFromTeam: {{http.StatusNoContent, ""}}},
+			expected:  []string{readMembership, removeFromTeam},
+		},
+		{
+			message:    "revoke from a user no longer in the team",
+			action:     revokeGrant,
+			responses:  map[string][]testResponse{readMembership: {{http.StatusOK, testMembershipNoTeam}}},
+			expected:   []string{readMembership},
+			annotation: "GrantAlreadyRevoked",
+		},
+		{
+			message: "revoke answered with not found after the member left the team",
+			action:  revokeGrant,
+			responses: map[string][]testResponse{
+				readMembership: {{http.StatusOK, testMembershipInTeam}, {http.StatusOK, testMembershipNoTeam}},
+				removeFromTeam: {{http.StatusNotFound, testNotFound}},
+			},
+			expected:   []string{readMembership, removeFromTeam, readMembership},
+			annotation: "GrantAlreadyRevoked",
+		},
+		{
+			message: "revoke answered with not found while still a member",
+			action:  revokeGrant,
+			responses: map[string][]testResponse{
+				readMembership: {{http.StatusOK, testMembershipInTeam}, {http.StatusOK, testMembershipInTeam}},
+				removeFromTeam: {{http.StatusNotFound, testNotFound}},
+			},
+			expected:    []string{readMembership, removeFromTeam, readMembership},
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			var requests []string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				request := r.Method + " " + r.URL.Path
+				requests = append(requests, request)
+				responses := testCase.responses[request]
+				if len(responses) == 0 {
+					t.Errorf("unexpected request %s", request)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				testCase.responses[request] = responses[1:]
+				body, _ := io.ReadAll(r.Body)
+				switch request {
+				case invite:
+					if !strings.Contains(string(body), `"team-1"`) {
+						t.Errorf("expected the user to be invited into the team, got %s", body)
+					}
+				case addToTeam, removeFromTeam:
+					if !strings.Contains(string(body), `"ou-1"`) {
+						t.Errorf("expected the membership ID in the request, got %s", body)
+					}
+				}
+				w.WriteHeader(responses[0].status)
+				_, _ = w.Write([]byte(responses[0].body))
+			})
+
+			orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+			team, err := newTeamResource(&tfe.Team{ID: "team-1", Name: "developers"}, orgID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := newUserResource(c, &client.OrganizationMembership{
+				ID:     "ou-1",
+				Status: tfe.OrganizationMembershipActive,
+				Email:  "jane@example.com",
+				User:   &tfe.User{ID: "user-1", Username: "jane", Email: "jane@example.com"},
+			}, orgID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entitlement := &v2.Entitlement{Id: "team:team-1:member", Resource: team}
+
+			var annos annotations.Annotations
+			builder := newTeamBuilder(c)
+			if testCase.action == grantEntitlement {
+				annos, err = builder.Grant(context.Background(), principal, entitlement)
+			} else {
+				annos, err = builder.Revoke(context.Background(), &v2.Grant{Entitlement: entitlement, Principal: principal})
+			}
+			if testCase.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", testCase.expectError, err)
+			}
+			if !reflect.DeepEqual(requests, testCase.expected) {
+				t.Errorf("expected the requests %v, got %v", testCase.expected, requests)
+			}
+
+			var annotation string
+			switch {
+			case annos.Contains(&v2.GrantAlreadyExists{}):
+				annotation = "GrantAlreadyExists"
+			case annos.Contains(&v2.GrantAlreadyRevoked{}):
+				annotation = "GrantAlreadyRevoked"
+			}
+			if annotation != testCase.annotation {
+				t.Errorf("expected the annotation %q, got %q", testCase.annotation, annotation)
+			}
+		})
+	}
+}
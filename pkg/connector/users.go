@@ -3,7 +3,11 @@ package connector
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -16,47 +20,132 @@ import (
 
 type userBuilder struct {
 	client *client.Client
+
+	// serviceAccounts holds, per organization, the service accounts already listed in the
+	// current pass over the teams. It lives outside the cache, which may evict it mid-sync.
+	serviceAccountsMu sync.Mutex
+	serviceAccounts   map[string]map[string]bool
 }
 
 func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return userResourceType
 }
 
-func newUserResource(user *tfe.User, parentID *v2.ResourceId) (*v2.Resource, error) {
-	profile := map[string]interface{}{
-		"email":             user.Email,
-		"twoFactorEnabled":  user.TwoFactor.Enabled,
-		"twoFactorVerified": user.TwoFactor.Verified,
-	}
-
+// userTraitOptions returns the structured identity fields shared by members and service accounts.
+// The user's email comes first and is the primary one, the other emails are added when they differ.
+func userTraitOptions(user *tfe.User, profile map[string]interface{}, emails ...string) []resourceSdk.UserTraitOption {
+	profile["email"] = user.Email
+	profile["isServiceAccount"] = user.IsServiceAccount
 	if user.IsAdmin != nil {
 		profile["isAdmin"] = *user.IsAdmin
 	}
 
+	mfaEnabled := false
+	if user.TwoFactor != nil {
+		mfaEnabled = user.TwoFactor.Enabled && user.TwoFactor.Verified
+		profile["twoFactorEnabled"] = user.TwoFactor.Enabled
+		profile["twoFactorVerified"] = user.TwoFactor.Verified
+	}
+
+	accountType := v2.UserTrait_ACCOUNT_TYPE_HUMAN
+	if user.IsServiceAccount {
+		accountType = v2.UserTrait_ACCOUNT_TYPE_SERVICE
+	}
+
+	options := []resourceSdk.UserTraitOption{
+		resourceSdk.WithUserProfile(profile),
+		resourceSdk.WithUserLogin(user.Username),
+		resourceSdk.WithAccountType(accountType),
+		resourceSdk.WithMFAStatus(&v2.UserTrait_MFAStatus{MfaEnabled: mfaEnabled}),
+		// last login data not available in terraform api as of 20/05/2025
+	}
+	if user.IsSsoLogin != nil {
+		options = append(options, resourceSdk.WithSSOStatus(&v2.UserTrait_SSOStatus{SsoEnabled: *user.IsSsoLogin}))
+	}
+
+	var added []string
+	for _, email := range append([]string{user.Email}, emails...) {
+		if email == "" || slices.Contains(added, email) {
+			continue
+		}
+		options = append(options, resourceSdk.WithEmail(email, len(added) == 0))
+		added = append(added, email)
+	}
+	return options
+}
+
+// newUserResource creates the user resource of an organization member. Pending invitations are
+// disabled users, flagged as expired once older than the client's invitation expiry.
+func newUserResource(c *client.Client, membership *client.OrganizationMembership, parentID *v2.ResourceId) (*v2.Resource, error) {
+	user := membership.User
+	profile := map[string]interface{}{
+		"organizationMembershipId": membership.ID,
+		"membershipStatus":         string(membership.Status),
+	}
+
 	name := user.Username
-	if user.Username == "" {
-		name = user.Email + "+invited"
+	if name == "" {
+		name = user.Email
 	}
+	if name == "" {
+		name = membership.Email
+	}
+
+	status := resourceSdk.WithStatus(v2.UserTrait_Status_STATUS_ENABLED)
+	if membership.Status == tfe.OrganizationMembershipInvited {
+		expired := c.InvitationExpired(membership)
+		details := "invitation pending"
+		if expired {
+			details = "invitation expired"
+		}
+		profile["invitationExpired"] = expired
+		if !membership.CreatedAt.IsZero() {
+			profile["invitedAt"] = membership.CreatedAt.Format(time.RFC3339)
+		}
+		status = resourceSdk.WithDetailedStatus(v2.UserTrait_Status_STATUS_DISABLED, details)
+	}
+
+	traitOptions := userTraitOptions(user, profile, membership.Email)
 
 	return resourceSdk.NewUserResource(
 		name,
 		userResourceType,
 		user.ID,
-		[]resourceSdk.UserTraitOption{
-			resourceSdk.WithUserProfile(profile),
-			// last login data not available in terraform api as of 20/05/2025
-		},
+		append(traitOptions, status),
+		resourceSdk.WithParentResourceID(parentID),
+	)
+}
+
+// newServiceAccountResource creates a user resource for the team and organization
+// service accounts, which have no organization membership of their own.
+func newServiceAccountResource(user *tfe.User, parentID *v2.ResourceId) (*v2.Resource, error) {
+	return resourceSdk.NewUserResource(
+		user.Username,
+		userResourceType,
+		user.ID,
+		userTraitOptions(user, map[string]interface{}{}),
 		resourceSdk.WithParentResourceID(parentID),
 	)
 }
 
+// serviceAccountsPagePrefix marks page tokens of the second listing phase, in which
+// service accounts are collected from the organization's teams.
+const serviceAccountsPagePrefix = "service-accounts:"
+
 // List returns all the users from the database as resource objects.
 // Users include a UserTrait because they are the 'shape' of a standard user.
+// Organization members are listed first, followed by the service accounts found in teams.
 func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
+	if strings.HasPrefix(pToken.Token, serviceAccountsPagePrefix) {
+		return o.listServiceAccounts(ctx, parentResourceID, strings.TrimPrefix(pToken.Token, serviceAccountsPagePrefix))
+	}
+
 	var page int
 	var err error
 	if pToken.Token != "" {
@@ -66,35 +155,130 @@ func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId,
 		}
 	}
 
-	// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/organization-memberships
-	memberships, err := o.client.OrganizationMemberships.List(ctx, parentResourceID.Resource, &tfe.OrganizationMembershipListOptions{
-		Include:     []tfe.OrgMembershipIncludeOpt{"user"},
-		ListOptions: client.ListOptions(page),
-	})
-
-	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list users: %w", err)
+	memberships, err := o.client.CachedOrganizationMemberships(ctx, parentResourceID.Resource, page)
+	if annos, ok := skipDenied(ctx, o.client, err, "users", parentResourceID.Resource); ok {
+		// service accounts are listed from the teams, which may still be readable.
+		return nil, serviceAccountsPagePrefix, annos, nil
 	}
-
-	if len(memberships.Items) == 0 {
-		return nil, "", nil, nil
+	if err != nil {
+		return nil, "", nil, client.WrapError(err, "failed to list users")
 	}
 
 	rv := []*v2.Resource{}
 	for _, membership := range memberships.Items {
-		resource, err := newUserResource(membership.User, parentResourceID)
+		resource, err := newUserResource(o.client, membership, parentResourceID)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create user resource: %w", err)
 		}
 		rv = append(rv, resource)
 	}
 
-	var nextPage string
+	nextPage := serviceAccountsPagePrefix
 	if memberships.CurrentPage < memberships.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+		nextPage = strconv.Itoa(memberships.NextPage)
+	}
+
+	return rv, nextPage, rateLimit.Annotations(), nil
+}
+
+// unlistedServiceAccounts returns the service accounts of the teams that were not listed yet for
+// the organization, and records them. The first page of the teams starts a new pass.
+func (o *userBuilder) unlistedServiceAccounts(orgName string, page int, teams []*tfe.Team) []*tfe.User {
+	o.serviceAccountsMu.Lock()
+	defer o.serviceAccountsMu.Unlock()
+
+	seen, ok := o.serviceAccounts[orgName]
+	if !ok || page == 0 {
+		seen = make(map[string]bool)
+		o.serviceAccounts[orgName] = seen
 	}
 
-	return rv, nextPage, nil, nil
+	var rv []*tfe.User
+	for _, team := range teams {
+		for _, user := range team.Users {
+			// a service account can be in several teams, on different pages.
+			if !user.IsServiceAccount || seen[user.ID] {
+				continue
+			}
+			seen[user.ID] = true
+			rv = append(rv, user)
+		}
+	}
+	return rv
+}
+
+func (o *userBuilder) listServiceAccounts(ctx context.Context, parentResourceID *v2.ResourceId, token string) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
+	var page int
+	var err error
+	if token != "" {
+		page, err = strconv.Atoi(token)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
+		}
+	}
+
+	teams, err := client.Call(ctx, func(ctx context.Context) (*tfe.TeamList, error) {
+		return o.client.Teams.List(ctx, parentResourceID.Resource, &tfe.TeamListOptions{
+			ListOptions: client.ListOptions(page),
+			Include: []tfe.TeamIncludeOpt{
+				"users",
+			},
+		})
+	})
+	if annos, ok := skipDenied(ctx, o.client, err, "the team service accounts", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
+	if err != nil {
+		return nil, "", nil, client.WrapError(err, "failed to list teams")
+	}
+
+	rv := []*v2.Resource{}
+	for _, user := range o.unlistedServiceAccounts(parentResourceID.Resource, page, teams.Items) {
+		resource, err := newServiceAccountResource(user, parentResourceID)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create service account resource: %w", err)
+		}
+		rv = append(rv, resource)
+	}
+
+	var nextPage string
+	if teams.CurrentPage < teams.TotalPages {
+		nextPage = serviceAccountsPagePrefix + strconv.Itoa(teams.NextPage)
+	}
+
+	return rv, nextPage, rateLimit.Annotations(), nil
+}
+
+// ownersTeam is the built-in team with full control over an organization.
+const ownersTeam = "owners"
+
+// parseTeamNames reads the teamNames account field, which arrives as a list of
+// values once the profile has gone through structpb, or as a comma separated string.
+func parseTeamNames(value interface{}) []string {
+	var raw []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				raw = append(raw, name)
+			}
+		}
+	case []string:
+		raw = v
+	case string:
+		raw = strings.Split(v, ",")
+	}
+
+	var rv []string
+	for _, name := range raw {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(rv, name) {
+			rv = append(rv, name)
+		}
+	}
+	return rv
 }
 
 func (o *userBuilder) CreateAccountCapabilityDetails(ctx context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
@@ -121,29 +305,58 @@ func (o *userBuilder) CreateAccount(ctx context.Context, accountInfo *v2.Account
 	if !ok {
 		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: organizationName not found in profile")
 	}
-	teamNames, ok := pMap["teamNames"].([]string)
-	if !ok {
-		teamNames = []string{"owners"}
+	if err := o.client.CheckOrganization(orgName); err != nil {
+		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: %w", err)
 	}
-
-	teams, err := o.client.Teams.List(ctx, orgName, &tfe.TeamListOptions{
-		Names: teamNames,
-	})
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: failed to list teams: %w", err)
+	teamNames := parseTeamNames(pMap["teamNames"])
+	for i, name := range teamNames {
+		// the schema lists teams as organization/team when several organizations are in scope
+		teamNames[i] = strings.TrimPrefix(name, orgName+"/")
+	}
+	addToOwners, _ := pMap["addToOwners"].(bool)
+	if addToOwners && !slices.Contains(teamNames, ownersTeam) {
+		teamNames = append(teamNames, ownersTeam)
+	}
+	if slices.Contains(teamNames, ownersTeam) && !addToOwners {
+		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: adding a user to the %q team requires addToOwners to be set", ownersTeam)
 	}
 
-	if len(teams.Items) == 0 {
-		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: no teams found for the given names")
+	var teams []*tfe.Team
+	if len(teamNames) > 0 {
+		teamList, err := client.Call(ctx, func(ctx context.Context) (*tfe.TeamList, error) {
+			return o.client.Teams.List(ctx, orgName, &tfe.TeamListOptions{
+				Names: teamNames,
+			})
+		})
+		if err != nil {
+			return nil, nil, nil, client.WrapError(err, "failed to list teams")
+		}
+
+		found := make(map[string]bool)
+		for _, team := range teamList.Items {
+			found[team.Name] = true
+		}
+		var missing []string
+		for _, name := range teamNames {
+			if !found[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: teams not found in organization %s: %s", orgName, strings.Join(missing, ", "))
+		}
+		teams = teamList.Items
 	}
 
-	orgMembership, err := o.client.OrganizationMemberships.Create(ctx, orgName, tfe.OrganizationMembershipCreateOptions{
-		Email: &email,
-		Teams: teams.Items,
+	orgMembership, err := client.Call(ctx, func(ctx context.Context) (*tfe.OrganizationMembership, error) {
+		return o.client.OrganizationMemberships.Create(ctx, orgName, tfe.OrganizationMembershipCreateOptions{
+			Email: &email,
+			Teams: teams,
+		})
 	})
 
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("baton-terraform-cloud: failed to create user: %w", err)
+		return nil, nil, nil, client.WrapError(err, "failed to create user")
 	}
 
 	return &v2.CreateAccountResponse_ActionRequiredResult{
@@ -163,6 +376,7 @@ func (o *userBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 
 func newUserBuilder(client *client.Client) *userBuilder {
 	return &userBuilder{
-		client: client,
+		client:          client,
+		serviceAccounts: make(map[string]map[string]bool),
 	}
 }
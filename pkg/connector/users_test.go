@@ -0,0 +1,217 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParseTeamNames(t *testing.T) {
+	profile, err := structpb.NewStruct(map[string]interface{}{
+		"teamNames": []interface{}{"developers", " ops ", "developers", ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		message  string
+		value    interface{}
+		expected []string
+	}{
+		{"structpb list", profile.AsMap()["teamNames"], []string{"developers", "ops"}},
+		{"string slice", []string{"owners"}, []string{"owners"}},
+		{"comma separated string", "developers, ops", []string{"developers", "ops"}},
+		{"missing", nil, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			actual := parseTeamNames(testCase.value)
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewUserResourceEmails(t *testing.T) {
+	type email struct {
+		address string
+		primary bool
+	}
+	testCases := []struct {
+		message         string
+		userEmail       string
+		membershipEmail string
+		expected        []email
+	}{
+		{"same email", "jane@example.com", "jane@example.com", []email{{"jane@example.com", true}}},
+		{"different emails", "jane@example.com", "jane@corp.example.com", []email{{"jane@example.com", true}, {"jane@corp.example.com", false}}},
+		{"invited member", "", "jane@example.com", []email{{"jane@example.com", true}}},
+		{"no email", "", "", nil},
+	}
+
+	c := &client.Client{}
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			resource, err := newUserResource(c, &client.OrganizationMembership{
+				ID:     "ou-1",
+				Status: tfe.OrganizationMembershipActive,
+				Email:  testCase.membershipEmail,
+				User:   &tfe.User{ID: "user-1", Username: "jane", Email: testCase.userEmail},
+			}, &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trait, err := resourceSdk.GetUserTrait(resource)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []email
+			for _, e := range trait.Emails {
+				actual = append(actual, email{e.Address, e.IsPrimary})
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestServiceAccountsListedOnce(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/organizations/acme/teams" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the service account is in a team on each page.
+		if r.URL.Query().Get("page[number]") == "2" {
+			_, _ = w.Write([]byte(`{"data":[{"id":"team-2","type":"teams","attributes":{"name":"operators"},
+"relationships":{"users":{"data":[{"id":"user-sa","type":"users"}]}}}],
+"included":[{"id":"user-sa","type":"users","attributes":{"username":"api-team_2","is-service-account":true}}],
+"meta":{"pagination":{"current-page":2,"prev-page":1,"total-pages":2}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"team-1","type":"teams","attributes":{"name":"developers"},
+"relationships":{"users":{"data":[{"id":"user-sa","type":"users"},{"id":"user-1","type":"users"}]}}}],
+"included":[{"id":"user-sa","type":"users","attributes":{"username":"api-team_2","is-service-account":true}},
+{"id":"user-1","type":"users","attributes":{"username":"jane"}}],
+"meta":{"pagination":{"current-page":1,"next-page":2,"total-pages":2}}}`))
+	})
+	users := newUserBuilder(c)
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+
+	var listed []string
+	token := serviceAccountsPagePrefix
+	for {
+		resources, next, _, err := users.List(context.Background(), orgID, &pagination.Token{Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, resource := range resources {
+			listed = append(listed, resource.Id.Resource)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+		// the accounts already listed are kept even if the cache is evicted mid-sync.
+		c.Cache.Clear()
+	}
+
+	if !reflect.DeepEqual(listed, []string{"user-sa"}) {
+		t.Errorf("expected the service account once, got %v", listed)
+	}
+}
+
+func TestUsersInvitationStatus(t *testing.T) {
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/organizations/acme/organization-memberships" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"data":[
+{"id":"ou-1","type":"organization-memberships","attributes":{"status":"active","email":"jane@example.com","created-at":%[1]q},
+"relationships":{"user":{"data":{"id":"user-1","type":"users"}}}},
+{"id":"ou-2","type":"organization-memberships","attributes":{"status":"invited","email":"john@example.com","created-at":%[1]q},
+"relationships":{"user":{"data":{"id":"user-2","type":"users"}}}},
+{"id":"ou-3","type":"organization-memberships","attributes":{"status":"invited","email":"joe@example.com","created-at":%[2]q},
+"relationships":{"user":{"data":{"id":"user-3","type":"users"}}}}],
+"included":[{"id":"user-1","type":"users","attributes":{"username":"jane"}},
+{"id":"user-2","type":"users","attributes":{}},{"id":"user-3","type":"users","attributes":{}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`, recent, old)
+	}
+
+	testCases := []struct {
+		message string
+		opts    []client.Option
+		details string
+		expired bool
+	}{
+		{"default expiry", nil, "invitation expired", true},
+		{"longer expiry", []client.Option{client.WithInvitationExpiry(60 * 24 * time.Hour)}, "invitation pending", false},
+		{"no expiry", []client.Option{client.WithInvitationExpiry(0)}, "invitation pending", false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			expectInvitationStatus(t, newTestClient(t, handler, testCase.opts...), testCase.details, testCase.expired)
+		})
+	}
+}
+
+// expectInvitationStatus lists the users of acme and checks the status of the active member,
+// the recent invitation and the old invitation.
+func expectInvitationStatus(t *testing.T, c *client.Client, oldDetails string, oldExpired bool) {
+	t.Helper()
+	users := newUserBuilder(c)
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+
+	resources, _, _, err := users.List(context.Background(), orgID, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type status struct {
+		name    string
+		status  v2.UserTrait_Status_Status
+		details string
+		expired interface{}
+	}
+	var actual []status
+	for _, resource := range resources {
+		trait, err := resourceSdk.GetUserTrait(resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, status{
+			name:    resource.DisplayName,
+			status:  trait.Status.Status,
+			details: trait.Status.Details,
+			expired: trait.Profile.AsMap()["invitationExpired"],
+		})
+	}
+	expected := []status{
+		{"jane", v2.UserTrait_Status_STATUS_ENABLED, "", nil},
+		{"john@example.com", v2.UserTrait_Status_STATUS_DISABLED, "invitation pending", false},
+		{"joe@example.com", v2.UserTrait_Status_STATUS_DISABLED, oldDetails, oldExpired},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+)
+
+// workspaceAccessLevels are the workspace access levels from the least to the most privileged.
+// Custom permissions cannot be ranked against them and come first, they are only reported when
+// no standard level applies.
+// https://developer.hashicorp.com/terraform/cloud-docs/users-teams-organizations/permissions/workspace
+var workspaceAccessLevels = []string{"custom", "read", "plan", "write", "admin"}
+
+// projectWorkspaceAccess maps project access levels to the access they give on the project's workspaces.
+// https://developer.hashicorp.com/terraform/cloud-docs/users-teams-organizations/permissions/project
+var projectWorkspaceAccess = map[tfe.TeamProjectAccessType]string{
+	tfe.TeamProjectAccessRead:     "read",
+	tfe.TeamProjectAccessWrite:    "write",
+	tfe.TeamProjectAccessMaintain: "admin",
+	tfe.TeamProjectAccessAdmin:    "admin",
+	tfe.TeamProjectAccessCustom:   "custom",
+}
+
+const ownersTeamName = "owners"
+
+// workspaceAccess is the effective access of a team on a workspace and where it comes from.
+type workspaceAccess struct {
+	// id is the ID of the team.
+	id      string
+	level   string
+	sources []string
+	// sourceID is the team giving the effective level.
+	sourceID string
+}
+
+func (a *workspaceAccess) add(level, source, sourceID string) {
+	if a.sourceID == "" || slices.Index(workspaceAccessLevels, level) > slices.Index(workspaceAccessLevels, a.level) {
+		a.level = level
+		a.sourceID = sourceID
+	}
+	a.sources = append(a.sources, source)
+}
+
+// accessByID collects workspace access by team ID.
+type accessByID map[string]*workspaceAccess
+
+func (m accessByID) get(id string) *workspaceAccess {
+	if a, ok := m[id]; ok {
+		return a
+	}
+	a := &workspaceAccess{id: id, level: workspaceAccessLevels[0]}
+	m[id] = a
+	return a
+}
+
+// sorted returns the access ordered by ID.
+func (m accessByID) sorted() []*workspaceAccess {
+	rv := make([]*workspaceAccess, 0, len(m))
+	for _, a := range m {
+		rv = append(rv, a)
+	}
+	slices.SortFunc(rv, func(a, b *workspaceAccess) int {
+		return strings.Compare(a.id, b.id)
+	})
+	return rv
+}
+
+// effectiveWorkspaceAccess resolves the highest access each team has on the workspace, from
+// direct team access, the access inherited from the workspace's project and organization wide
+// rights. The result is ordered by team ID.
+func effectiveWorkspaceAccess(ctx context.Context, c *client.Client, orgName, workspaceID, projectID string) ([]*workspaceAccess, error) {
+	byTeam := accessByID{}
+
+	teams, err := organizationTeams(ctx, c, orgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	for _, team := range teams {
+		switch {
+		case team.Name == ownersTeamName:
+			byTeam.get(team.ID).add("admin", "organization:owners", team.ID)
+		case team.OrganizationAccess == nil:
+		case team.OrganizationAccess.ManageWorkspaces:
+			byTeam.get(team.ID).add("admin", "organization:manage-workspaces", team.ID)
+		case team.OrganizationAccess.ManageProjects:
+			// managing every project gives admin access to their workspaces.
+			byTeam.get(team.ID).add("admin", "organization:manage-projects", team.ID)
+		case team.OrganizationAccess.ReadWorkspaces:
+			byTeam.get(team.ID).add("read", "organization:read-workspaces", team.ID)
+		}
+	}
+
+	if projectID != "" {
+		projectAccess, err := c.ProjectTeamAccess(ctx, orgName, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project team access: %w", err)
+		}
+		for _, item := range projectAccess {
+			if item.Team == nil {
+				continue
+			}
+			level, ok := projectWorkspaceAccess[item.Access]
+			if !ok {
+				continue
+			}
+			byTeam.get(item.Team.ID).add(level, "project:"+string(item.Access), item.Team.ID)
+		}
+	}
+
+	teamAccess, err := c.WorkspaceTeamAccess(ctx, orgName, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace team access: %w", err)
+	}
+	for _, item := range teamAccess {
+		if item.Team == nil {
+			continue
+		}
+		byTeam.get(item.Team.ID).add(string(item.Access), "workspace:"+string(item.Access), item.Team.ID)
+	}
+
+	return byTeam.sorted(), nil
+}
@@ -0,0 +1,141 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestWorkspaceAccessAdd(t *testing.T) {
+	testCases := []struct {
+		message  string
+		levels   []string
+		expected string
+	}{
+		{"single level", []string{"read"}, "read"},
+		{"highest wins", []string{"write", "read", "admin", "plan"}, "admin"},
+		{"custom alone", []string{"custom"}, "custom"},
+		{"standard level over custom", []string{"custom", "plan"}, "plan"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			access := &workspaceAccess{level: workspaceAccessLevels[0]}
+			for _, level := range testCase.levels {
+				access.add(level, "workspace:"+level, "team-1")
+			}
+			if access.level != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, access.level)
+			}
+			if len(access.sources) != len(testCase.levels) {
+				t.Errorf("expected %d sources, got %v", len(testCase.levels), access.sources)
+			}
+		})
+	}
+}
+
+func TestProjectWorkspaceAccessLevels(t *testing.T) {
+	for projectAccess, level := range projectWorkspaceAccess {
+		found := false
+		for _, known := range workspaceAccessLevels {
+			found = found || known == level
+		}
+		if !found {
+			t.Errorf("project access %s maps to unknown workspace level %s", projectAccess, level)
+		}
+	}
+	if workspaceAccessLevels[len(workspaceAccessLevels)-1] != "admin" {
+		t.Errorf("expected admin to be the most privileged level")
+	}
+}
+
+func TestWorkspaceAccessGrants(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/entitlement-set":
+			_, _ = w.Write([]byte(`{"data":{"id":"acme","type":"entitlement-sets","attributes":{"teams":true}}}`))
+		case "/api/v2/organizations/acme/teams":
+			_, _ = w.Write([]byte(`{"data":[
+{"id":"team-0","type":"teams","attributes":{"name":"owners"}},
+{"id":"team-1","type":"teams","attributes":{"name":"developers","organization-access":{"read-workspaces":true}}},
+{"id":"team-2","type":"teams","attributes":{"name":"operators"}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/api/v2/team-projects":
+			_, _ = w.Write([]byte(`{"data":[{"id":"tprj-1","type":"team-projects","attributes":{"access":"write"},"relationships":{
+"team":{"data":{"id":"team-2","type":"teams"}},"project":{"data":{"id":"prj-1","type":"projects"}}}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/api/v2/team-workspaces":
+			_, _ = w.Write([]byte(`{"data":[{"id":"tws-1","type":"team-workspaces","attributes":{"access":"plan"},"relationships":{
+"team":{"data":{"id":"team-1","type":"teams"}},"workspace":{"data":{"id":"ws-1","type":"workspaces"}}}}],
+"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	resource, err := newWorkspaceResource(&tfe.Workspace{ID: "ws-1", Name: "network", Project: &tfe.Project{ID: "prj-1"}}, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grants, next, _, err := newWorkspaceBuilder(c).Grants(context.Background(), resource, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != stateConsumersPagePrefix+"0" {
+		t.Errorf("expected the state consumers to follow, got next page %q", next)
+	}
+
+	type expectedGrant struct {
+		level    string
+		sourceID string
+	}
+	expected := map[string]expectedGrant{
+		"team:team-0": {"admin", "team-0"},
+		"team:team-1": {"plan", "team-1"},
+		"team:team-2": {"write", "team-2"},
+	}
+	if len(grants) != len(expected) {
+		t.Fatalf("expected %d grants, got %d: %v", len(expected), len(grants), grants)
+	}
+	for _, g := range grants {
+		key := g.Principal.Id.ResourceType + ":" + g.Principal.Id.Resource
+		want, ok := expected[key]
+		if !ok {
+			t.Errorf("unexpected grant to %s", key)
+			continue
+		}
+		if g.Entitlement.Id != entitlement.NewEntitlementID(resource, want.level) {
+			t.Errorf("expected %s to have %s access, got %s", key, want.level, g.Entitlement.Id)
+		}
+		immutable := &v2.GrantImmutable{}
+		annos := annotations.Annotations(g.Annotations)
+		if ok, err := annos.Pick(immutable); err != nil || !ok {
+			t.Errorf("expected %s to have a GrantImmutable annotation", key)
+			continue
+		}
+		if immutable.SourceId != want.sourceID {
+			t.Errorf("expected the access of %s to come from %s, got %s", key, want.sourceID, immutable.SourceId)
+		}
+		if len(immutable.Metadata.AsMap()["sources"].([]interface{})) == 0 {
+			t.Errorf("expected the sources of %s to be listed", key)
+		}
+		expandable := &v2.GrantExpandable{}
+		if ok, err := annos.Pick(expandable); err != nil || !ok {
+			t.Errorf("expected %s to be expanded to its members", key)
+			continue
+		}
+		if want := []string{key + ":" + teamMembership}; !slices.Equal(expandable.EntitlementIds, want) {
+			t.Errorf("expected %s to be expanded through %v, got %v", key, want, expandable.EntitlementIds)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	config "github.com/conductorone/baton-sdk/pb/c1/config/v1"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	lockWorkspaceAction         = "lock_workspace"
+	forceUnlockWorkspaceAction  = "force_unlock_workspace"
+	lockProjectWorkspacesAction = "lock_project_workspaces"
+	discardUserRunsAction       = "discard_user_runs"
+)
+
+// runStatusGroupNonFinal selects the runs that are still pending or in progress.
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/run#list-runs-in-an-organization
+const runStatusGroupNonFinal = "non_final"
+
+func (o *workspaceBuilder) registerActions(am *actionManager) {
+	am.register(&v2.BatonActionSchema{
+		Name:        lockWorkspaceAction,
+		DisplayName: "Lock workspace",
+		Description: "Lock a workspace so that no new runs can apply changes.",
+		Arguments: []*config.Field{
+			stringActionField("workspace_id", "Workspace ID", "The ID of the workspace to lock.", true),
+			stringActionField("reason", "Reason", "Why the workspace is locked, shown to its users.", false),
+		},
+	}, o.lockWorkspace)
+
+	am.register(&v2.BatonActionSchema{
+		Name:        forceUnlockWorkspaceAction,
+		DisplayName: "Force unlock workspace",
+		Description: "Unlock a workspace, whoever locked it.",
+		Arguments: []*config.Field{
+			stringActionField("workspace_id", "Workspace ID", "The ID of the workspace to unlock.", true),
+		},
+	}, o.forceUnlockWorkspace)
+
+	am.register(&v2.BatonActionSchema{
+		Name:        lockProjectWorkspacesAction,
+		DisplayName: "Lock project workspaces",
+		Description: "Lock every workspace in a project.",
+		Arguments: []*config.Field{
+			stringActionField("organization", "Organization", "The name of the organization the project belongs to.", true),
+			stringActionField("project_id", "Project ID", "The ID of the project whose workspaces are locked.", true),
+			stringActionField("reason", "Reason", "Why the workspaces are locked, shown to their users.", false),
+		},
+	}, o.lockProjectWorkspaces)
+
+	am.register(&v2.BatonActionSchema{
+		Name:        discardUserRunsAction,
+		DisplayName: "Discard user runs",
+		Description: "Discard the pending runs created by a user, and cancel the ones already planning or applying.",
+		Arguments: []*config.Field{
+			stringActionField("organization", "Organization", "The name of the organization to search for runs.", true),
+			stringActionField("username", "Username", "The username of the user who created the runs.", true),
+			stringActionField("comment", "Comment", "An explanation recorded on the discarded runs.", false),
+		},
+	}, o.discardUserRuns)
+}
+
+// readWorkspace reads a workspace by ID and fails unless its organization is in scope.
+func (o *workspaceBuilder) readWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	workspace, err := client.Call(ctx, func(ctx context.Context) (*tfe.Workspace, error) {
+		return o.client.Workspaces.ReadByID(ctx, workspaceID)
+	})
+	if err != nil {
+		return nil, client.WrapError(err, "failed to read workspace")
+	}
+	if workspace.Organization == nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: workspace %s has no organization", workspaceID)
+	}
+	if err := o.client.CheckOrganization(workspace.Organization.Name); err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+	return workspace, nil
+}
+
+// lock locks the workspace and reports whether it was already locked.
+func (o *workspaceBuilder) lock(ctx context.Context, workspaceID, reason string) (bool, error) {
+	options := tfe.WorkspaceLockOptions{}
+	if reason != "" {
+		options.Reason = &reason
+	}
+	_, err := client.Call(ctx, func(ctx context.Context) (*tfe.Workspace, error) {
+		return o.client.Workspaces.Lock(ctx, workspaceID, options)
+	})
+	if errors.Is(err, tfe.ErrWorkspaceLocked) {
+		return true, nil
+	}
+	return false, err
+}
+
+func (o *workspaceBuilder) lockWorkspace(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	workspaceID, err := getStringArg(args, "workspace_id")
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := o.readWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	alreadyLocked, err := o.lock(ctx, workspace.ID, getOptionalStringArg(args, "reason"))
+	if err != nil {
+		return nil, client.WrapError(err, "failed to lock workspace")
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"workspace_id":   workspace.ID,
+		"already_locked": alreadyLocked,
+	})
+}
+
+func (o *workspaceBuilder) forceUnlockWorkspace(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	workspaceID, err := getStringArg(args, "workspace_id")
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := o.readWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	alreadyUnlocked := false
+	_, err = client.Call(ctx, func(ctx context.Context) (*tfe.Workspace, error) {
+		return o.client.Workspaces.ForceUnlock(ctx, workspace.ID)
+	})
+	switch {
+	case errors.Is(err, tfe.ErrWorkspaceNotLocked):
+		alreadyUnlocked = true
+	case err != nil:
+		return nil, client.WrapError(err, "failed to unlock workspace")
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"workspace_id":     workspace.ID,
+		"already_unlocked": alreadyUnlocked,
+	})
+}
+
+// lockProjectWorkspaces locks the project's workspaces one by one. A failure on one workspace
+// does not stop the others, the failures are reported with the locked workspaces.
+func (o *workspaceBuilder) lockProjectWorkspaces(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	orgName, err := getStringArg(args, "organization")
+	if err != nil {
+		return nil, err
+	}
+	projectID, err := getStringArg(args, "project_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := o.client.CheckOrganization(orgName); err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+	reason := getOptionalStringArg(args, "reason")
+
+	locked := []interface{}{}
+	alreadyLocked := []interface{}{}
+	failed := map[string]interface{}{}
+	page := 0
+	for {
+		workspaces, err := client.Call(ctx, func(ctx context.Context) (*tfe.WorkspaceList, error) {
+			return o.client.Workspaces.List(ctx, orgName, &tfe.WorkspaceListOptions{
+				ProjectID:   projectID,
+				ListOptions: client.ListOptions(page),
+			})
+		})
+		if err != nil {
+			return nil, client.WrapError(err, "failed to list project workspaces")
+		}
+		for _, workspace := range workspaces.Items {
+			wasLocked, err := o.lock(ctx, workspace.ID, reason)
+			switch {
+			case err != nil:
+				failed[workspace.ID] = err.Error()
+			case wasLocked:
+				alreadyLocked = append(alreadyLocked, workspace.ID)
+			default:
+				locked = append(locked, workspace.ID)
+			}
+		}
+		if workspaces.Pagination == nil || workspaces.NextPage == 0 {
+			break
+		}
+		page = workspaces.NextPage
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"project_id":     projectID,
+		"locked":         locked,
+		"already_locked": alreadyLocked,
+		"failed":         failed,
+	})
+}
+
+// discardUserRuns discards the user's runs that wait for confirmation or in a queue, and
+// cancels the ones that are planning or applying, in one organization.
+func (o *workspaceBuilder) discardUserRuns(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+	orgName, err := getStringArg(args, "organization")
+	if err != nil {
+		return nil, err
+	}
+	username, err := getStringArg(args, "username")
+	if err != nil {
+		return nil, err
+	}
+	if err := o.client.CheckOrganization(orgName); err != nil {
+		return nil, fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+
+	report, err := discardRunsCreatedBy(ctx, o.client, orgName, username, getOptionalStringArg(args, "comment"))
+	if err != nil {
+		return nil, err
+	}
+	report["username"] = username
+	return structpb.NewStruct(report)
+}
+
+// discardRunsCreatedBy discards the user's runs that wait for confirmation or in a queue, and
+// cancels the ones that are planning or applying. It returns what was discarded, canceled
+// and failed, failures on single runs do not stop the others.
+func discardRunsCreatedBy(ctx context.Context, c *client.Client, orgName, username, comment string) (map[string]interface{}, error) {
+	var runComment *string
+	if comment != "" {
+		runComment = &comment
+	}
+
+	discarded := []interface{}{}
+	canceled := []interface{}{}
+	failed := map[string]interface{}{}
+	page := 0
+	for {
+		runs, err := c.ListRunsForUser(ctx, orgName, &client.RunListForUserOptions{
+			RunListForOrganizationOptions: tfe.RunListForOrganizationOptions{
+				ListOptions: client.ListOptions(page),
+				StatusGroup: runStatusGroupNonFinal,
+				Include:     []tfe.RunIncludeOpt{tfe.RunCreatedBy},
+			},
+			Username: username,
+		})
+		if err != nil {
+			return nil, client.WrapError(err, "failed to list runs")
+		}
+		for _, run := range runs.Items {
+			// the filter is checked again, runs of other users must never be discarded.
+			if run.CreatedBy == nil || run.CreatedBy.Username != username || run.Actions == nil {
+				continue
+			}
+			switch {
+			case run.Actions.IsDiscardable:
+				if err := client.CallErr(ctx, func(ctx context.Context) error {
+					return c.Runs.Discard(ctx, run.ID, tfe.RunDiscardOptions{Comment: runComment})
+				}); err != nil {
+					failed[run.ID] = client.WrapError(err, "failed to discard run").Error()
+					continue
+				}
+				discarded = append(discarded, run.ID)
+			case run.Actions.IsCancelable:
+				if err := client.CallErr(ctx, func(ctx context.Context) error {
+					return c.Runs.Cancel(ctx, run.ID, tfe.RunCancelOptions{Comment: runComment})
+				}); err != nil {
+					failed[run.ID] = client.WrapError(err, "failed to cancel run").Error()
+					continue
+				}
+				canceled = append(canceled, run.ID)
+			}
+		}
+		if runs.PaginationNextPrev == nil || runs.NextPage == 0 {
+			break
+		}
+		page = runs.NextPage
+	}
+
+	return map[string]interface{}{
+		"discarded": discarded,
+		"canceled":  canceled,
+		"failed":    failed,
+	}, nil
+}
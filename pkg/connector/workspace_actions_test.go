@@ -0,0 +1,139 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const testWorkspace = `{"data":{"id":"ws-1","type":"workspaces","attributes":{"name":"network"},
+"relationships":{"organization":{"data":{"id":"acme","type":"organizations"}}}}}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...client.Option) *client.Client {
+	t.Helper()
+	return newVersionedTestClient(t, http.Header{"Tfp-Appname": {"HCP Terraform"}}, handler, opts...)
+}
+
+// newVersionedTestClient returns a client for a test server announcing the instance with
+// the version headers.
+func newVersionedTestClient(t *testing.T, versionHeaders http.Header, handler http.HandlerFunc, opts ...client.Option) *client.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		for name, values := range versionHeaders {
+			w.Header()[name] = values
+		}
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.New("token", server.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLockWorkspaceAction(t *testing.T) {
+	testCases := []struct {
+		message       string
+		lockStatus    int
+		alreadyLocked bool
+	}{
+		{"unlocked workspace", http.StatusOK, false},
+		{"locked workspace", http.StatusConflict, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v2/workspaces/ws-1":
+					_, _ = w.Write([]byte(testWorkspace))
+				case "/api/v2/workspaces/ws-1/actions/lock":
+					w.WriteHeader(testCase.lockStatus)
+					_, _ = w.Write([]byte(testWorkspace))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			am := newActionManager()
+			newWorkspaceBuilder(c).registerActions(am)
+
+			args, err := structpb.NewStruct(map[string]interface{}{"workspace_id": "ws-1", "reason": "incident"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, status, _, _, err := am.InvokeAction(context.Background(), lockWorkspaceAction, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE {
+				t.Fatalf("expected a completed action, got %s", status)
+			}
+
+			status, name, resp, _, err := am.GetActionStatus(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != v2.BatonActionStatus_BATON_ACTION_STATUS_COMPLETE || name != lockWorkspaceAction {
+				t.Errorf("unexpected status %s for %s", status, name)
+			}
+			if resp.GetFields()["already_locked"].GetBoolValue() != testCase.alreadyLocked {
+				t.Errorf("expected already_locked %v, got %v", testCase.alreadyLocked, resp.AsMap())
+			}
+		})
+	}
+}
+
+func TestDiscardRunsCreatedBy(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/organizations/acme/runs":
+			if user := r.URL.Query().Get("filter[user]"); user != "jane" {
+				t.Errorf("expected the runs to be filtered on jane, got %q", user)
+			}
+			if group := r.URL.Query().Get("filter[status_group]"); group != runStatusGroupNonFinal {
+				t.Errorf("expected the %s runs, got %q", runStatusGroupNonFinal, group)
+			}
+			_, _ = w.Write([]byte(`{"data":[
+{"id":"run-1","type":"runs","attributes":{"actions":{"is-discardable":true}},"relationships":{"created-by":{"data":{"id":"user-1","type":"users"}}}},
+{"id":"run-2","type":"runs","attributes":{"actions":{"is-cancelable":true}},"relationships":{"created-by":{"data":{"id":"user-1","type":"users"}}}},
+{"id":"run-3","type":"runs","attributes":{"actions":{"is-discardable":true}},"relationships":{"created-by":{"data":{"id":"user-2","type":"users"}}}}],
+"included":[
+{"id":"user-1","type":"users","attributes":{"username":"jane"}},
+{"id":"user-2","type":"users","attributes":{"username":"joe"}}]}`))
+		case "POST /api/v2/runs/run-1/actions/discard", "POST /api/v2/runs/run-2/actions/cancel":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	report, err := discardRunsCreatedBy(context.Background(), c, "acme", "jane", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]interface{}{
+		"discarded": []interface{}{"run-1"},
+		"canceled":  []interface{}{"run-2"},
+		"failed":    map[string]interface{}{},
+	} {
+		if !reflect.DeepEqual(report[key], expected) {
+			t.Errorf("expected %s %v, got %v", key, expected, report[key])
+		}
+	}
+}
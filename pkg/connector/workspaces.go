@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"sync"
+	"strings"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
@@ -13,51 +14,77 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/hashicorp/go-tfe"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
-const workspaceMembership = "member"
+const workspaceStateConsumer = "state_consumer"
+
+// stateConsumersPagePrefix marks page tokens of the remote state consumer listing, which
+// follows the project grant.
+const stateConsumersPagePrefix = "state-consumers:"
 
 type workspaceBuilder struct {
-	client           *client.Client
-	m                *sync.Mutex
-	workspaceProject map[string]*tfe.Project
+	client *client.Client
 }
 
 func (o *workspaceBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return workspaceResourceType
 }
 
-func (o *workspaceBuilder) cacheWorkspacesProject(workspaces *tfe.WorkspaceList) {
-	o.m.Lock()
-	defer o.m.Unlock()
+func workspaceProjectCacheKey(workspaceID string) string {
+	return "workspace-project:" + workspaceID
+}
 
+func (o *workspaceBuilder) cacheWorkspacesProject(workspaces *tfe.WorkspaceList) {
 	for _, workspace := range workspaces.Items {
 		if workspace.Project == nil {
 			continue
 		}
-		o.workspaceProject[workspace.ID] = workspace.Project
+		o.client.Cache.Set(workspaceProjectCacheKey(workspace.ID), workspace.Project.ID)
 	}
 }
 
-func (o *workspaceBuilder) getWorkspaceProject(ctx context.Context, workspaceID, parentID string) (*tfe.Project, error) {
-	o.m.Lock()
-	defer o.m.Unlock()
+// getWorkspaceProject returns the ID of the workspace's project, preferring the one recorded
+// on the resource profile, then the cache filled by List, and reading the workspace last.
+func (o *workspaceBuilder) getWorkspaceProject(ctx context.Context, resource *v2.Resource) (string, error) {
+	groupTrait, err := resourceSdk.GetGroupTrait(resource)
+	if err == nil {
+		if projectID, ok := resourceSdk.GetProfileStringValue(groupTrait.GetProfile(), "projectId"); ok && projectID != "" {
+			return projectID, nil
+		}
+	}
 
-	project, ok := o.workspaceProject[workspaceID]
-	if ok {
-		return project, nil
+	if projectID, ok := client.CacheGet[string](o.client.Cache, workspaceProjectCacheKey(resource.Id.Resource)); ok {
+		return projectID, nil
 	}
 
-	workspace, err := o.client.Workspaces.Read(ctx, parentID, workspaceID)
+	workspace, err := client.Call(ctx, func(ctx context.Context) (*tfe.Workspace, error) {
+		return o.client.Workspaces.ReadByID(ctx, resource.Id.Resource)
+	})
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if workspace.Project == nil {
+		return "", fmt.Errorf("workspace %s has no project", resource.Id.Resource)
 	}
 
-	return workspace.Project, nil
+	o.client.Cache.Set(workspaceProjectCacheKey(resource.Id.Resource), workspace.Project.ID)
+
+	return workspace.Project.ID, nil
 }
 
 func newWorkspaceResource(workspace *tfe.Workspace, parentID *v2.ResourceId) (*v2.Resource, error) {
+	tags := client.WorkspaceTags(workspace)
+	// structpb only converts untyped slices.
+	tagValues := make([]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		tagValues = append(tagValues, tag)
+	}
+
 	profile := map[string]interface{}{
 		"workingDirectory": workspace.WorkingDirectory,
 		"terraformVersion": workspace.TerraformVersion,
@@ -69,6 +96,47 @@ func newWorkspaceResource(workspace *tfe.Workspace, parentID *v2.ResourceId) (*v
 		"autoApply":        workspace.AutoApply,
 		"resourceCount":    workspace.ResourceCount,
 		"executionMode":    workspace.ExecutionMode,
+		"locked":           workspace.Locked,
+		"tags":             tagValues,
+		// every workspace in the organization can read the state when this is set.
+		"globalRemoteState": workspace.GlobalRemoteState,
+	}
+
+	if workspace.Project != nil {
+		profile["projectId"] = workspace.Project.ID
+	}
+	if workspace.VCSRepo != nil {
+		profile["vcsRepoIdentifier"] = workspace.VCSRepo.Identifier
+		profile["vcsRepoBranch"] = workspace.VCSRepo.Branch
+		profile["vcsServiceProvider"] = workspace.VCSRepo.ServiceProvider
+	}
+	if workspace.AgentPool != nil {
+		profile["agentPoolId"] = workspace.AgentPool.ID
+	}
+	if !workspace.CreatedAt.IsZero() {
+		profile["createdAt"] = workspace.CreatedAt.Format(time.RFC3339)
+	}
+	if !workspace.UpdatedAt.IsZero() {
+		profile["updatedAt"] = workspace.UpdatedAt.Format(time.RFC3339)
+	}
+	if run := workspace.CurrentRun; run != nil {
+		profile["lastRunId"] = run.ID
+		profile["lastRunStatus"] = string(run.Status)
+		if !run.CreatedAt.IsZero() {
+			profile["lastRunAt"] = run.CreatedAt.Format(time.RFC3339)
+		}
+	}
+
+	options := []resourceSdk.ResourceOption{
+		resourceSdk.WithParentResourceID(parentID),
+	}
+	if len(tagValues) > 0 {
+		// the SDK has no tag annotation, tags are attached as a struct so approvals can be routed on them.
+		tagAnnotation, err := structpb.NewStruct(map[string]interface{}{"tags": tagValues})
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, resourceSdk.WithAnnotation(tagAnnotation))
 	}
 
 	return resourceSdk.NewGroupResource(
@@ -78,34 +146,72 @@ func newWorkspaceResource(workspace *tfe.Workspace, parentID *v2.ResourceId) (*v
 		[]resourceSdk.GroupTraitOption{
 			resourceSdk.WithGroupProfile(profile),
 		},
-		resourceSdk.WithParentResourceID(parentID),
+		options...,
 	)
 }
 
+// listWorkspaces reads a page of the organization's workspaces in scope, of one project when
+// the sync is scoped to projects. The first page is shared with the organization's sync gap probe.
+func listWorkspaces(ctx context.Context, c *client.Client, orgName, projectID string, page int) (*tfe.WorkspaceList, error) {
+	return sharedFirstPage(ctx, c, "workspaces-page:"+orgName+":"+projectID, page, func(ctx context.Context) (*tfe.WorkspaceList, error) {
+		return c.Workspaces.List(ctx, orgName, c.WorkspaceListOptions(projectID, page))
+	})
+}
+
+// parseWorkspacePageToken reads the page token of List. When the sync is scoped to projects,
+// the token also holds the index of the project being listed, as "<index>:<page>".
+func parseWorkspacePageToken(token string) (int, int, error) {
+	if token == "" {
+		return 0, 0, nil
+	}
+	index, page, scoped := strings.Cut(token, ":")
+	if !scoped {
+		page, err := strconv.Atoi(token)
+		return 0, page, err
+	}
+	projectIndex, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, 0, err
+	}
+	pageNumber, err := strconv.Atoi(page)
+	return projectIndex, pageNumber, err
+}
+
+// List pages through the organization's workspaces. When the sync is scoped to projects, the
+// workspaces of each project are listed in turn instead.
 func (o *workspaceBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
 
-	var page int
-	var err error
-	if pToken.Token != "" {
-		page, err = strconv.Atoi(pToken.Token)
-		if err != nil {
-			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
-		}
+	projectIndex, page, err := parseWorkspacePageToken(pToken.Token)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
 	}
 
-	workspaces, err := o.client.Workspaces.List(ctx, parentResourceID.Resource, &tfe.WorkspaceListOptions{
-		ListOptions: client.ListOptions(page),
-	})
-
+	projectIDs, err := o.client.ScopedProjectIDs(ctx, parentResourceID.Resource)
+	if annos, ok := skipDenied(ctx, o.client, err, "projects", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to list workspaces: %w", err)
+		return nil, "", nil, client.WrapError(err, "failed to list projects")
+	}
+	var projectID string
+	if projectIDs != nil {
+		if projectIndex >= len(projectIDs) {
+			return nil, "", nil, nil
+		}
+		projectID = projectIDs[projectIndex]
 	}
 
-	if len(workspaces.Items) == 0 {
-		return nil, "", nil, nil
+	workspaces, err := listWorkspaces(ctx, o.client, parentResourceID.Resource, projectID, page)
+	if annos, ok := skipDenied(ctx, o.client, err, "workspaces", parentResourceID.Resource); ok {
+		return nil, "", annos, nil
+	}
+	if err != nil {
+		return nil, "", nil, client.WrapError(err, "failed to list workspaces")
 	}
 
 	// Cache the projects for the workspaces
@@ -113,6 +219,9 @@ func (o *workspaceBuilder) List(ctx context.Context, parentResourceID *v2.Resour
 
 	rv := []*v2.Resource{}
 	for _, workspace := range workspaces.Items {
+		if !o.client.WorkspaceInScope(workspace) {
+			continue
+		}
 		resource, err := newWorkspaceResource(workspace, parentResourceID)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create workspace resource: %w", err)
@@ -121,67 +230,273 @@ func (o *workspaceBuilder) List(ctx context.Context, parentResourceID *v2.Resour
 	}
 
 	var nextPage string
-	if workspaces.CurrentPage < workspaces.TotalPages {
-		nextPage = strconv.Itoa(page + 1)
+	switch {
+	case workspaces.Pagination != nil && workspaces.CurrentPage < workspaces.TotalPages && projectIDs == nil:
+		nextPage = strconv.Itoa(workspaces.NextPage)
+	case workspaces.Pagination != nil && workspaces.CurrentPage < workspaces.TotalPages:
+		nextPage = fmt.Sprintf("%d:%d", projectIndex, workspaces.NextPage)
+	case projectIndex+1 < len(projectIDs):
+		nextPage = fmt.Sprintf("%d:0", projectIndex+1)
 	}
 
-	return rv, nextPage, nil, nil
+	return rv, nextPage, rateLimit.Annotations(), nil
 }
 
 func (o *workspaceBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return []*v2.Entitlement{
-		entitlement.NewAssignmentEntitlement(
+	rv := make([]*v2.Entitlement, 0, len(workspaceAccessLevels)+1)
+	for _, level := range workspaceAccessLevels {
+		rv = append(rv, entitlement.NewPermissionEntitlement(
+			resource,
+			level,
+			entitlement.WithGrantableTo(teamResourceType, userResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Workspace access level %s on %s workspace", level, resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("Workspace access level %s", level)),
+		))
+	}
+
+	return append(rv,
+		entitlement.NewPermissionEntitlement(
 			resource,
-			teamMembership,
-			entitlement.WithGrantableTo(userResourceType),
-			entitlement.WithDescription(fmt.Sprintf("Member of %s workspace", resource.DisplayName)),
-			entitlement.WithDisplayName(fmt.Sprintf("Member of %s workspace", resource.DisplayName)),
+			workspaceStateConsumer,
+			entitlement.WithGrantableTo(workspaceResourceType),
+			entitlement.WithDescription(fmt.Sprintf("Can read the state of %s workspace, including its sensitive outputs", resource.DisplayName)),
+			entitlement.WithDisplayName(fmt.Sprintf("State consumer of %s workspace", resource.DisplayName)),
 		),
-	}, "", nil, nil
+	), "", nil, nil
 }
 
 func (o *workspaceBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	project, err := o.getWorkspaceProject(ctx, resource.Id.Resource, resource.ParentResourceId.Resource)
-	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to get workspace project: %w", err)
+	ctx, rateLimit := client.TrackRateLimit(ctx)
+
+	if strings.HasPrefix(pToken.Token, stateConsumersPagePrefix) {
+		page, err := strconv.Atoi(strings.TrimPrefix(pToken.Token, stateConsumersPagePrefix))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to parse page token: %w", err)
+		}
+		rv, nextPage, err := o.stateConsumerGrants(ctx, resource, page)
+		if annos, ok := skipDenied(ctx, o.client, err, "the remote state consumers of workspace "+resource.Id.Resource, resource.ParentResourceId.GetResource()); ok {
+			return nil, "", annos, nil
+		}
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return rv, nextPage, rateLimit.Annotations(), nil
 	}
 
-	pr, err := newProjectResource(project, resource.ParentResourceId)
+	rv, err := o.accessGrants(ctx, resource)
+	if annos, ok := skipDenied(ctx, o.client, err, "the team access of workspace "+resource.Id.Resource, resource.ParentResourceId.GetResource()); ok {
+		return nil, stateConsumersPagePrefix + "0", annos, nil
+	}
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create project resource: %w", err)
+		return nil, "", nil, err
 	}
 
-	entitlementIDs := []string{}
-	for _, p := range permissions {
-		entitlementIDs = append(entitlementIDs, entitlement.NewEntitlementID(pr, p))
+	return rv, stateConsumersPagePrefix + "0", rateLimit.Annotations(), nil
+}
+
+// accessGrants grants each team its effective access level on the workspace, expanded to the
+// team's members like the project grants. The access is derived from team, project and
+// organization settings and cannot be revoked on the workspace, the GrantImmutable annotation
+// names the team giving the level and lists every source.
+func (o *workspaceBuilder) accessGrants(ctx context.Context, resource *v2.Resource) ([]*v2.Grant, error) {
+	orgName := resource.ParentResourceId.GetResource()
+	if !featureEnabled(ctx, o.client, orgName, client.FeatureTeams) {
+		return nil, nil
 	}
 
-	grantOptions := []grant.GrantOption{
-		grant.WithAnnotation(&v2.GrantExpandable{
-			EntitlementIds: entitlementIDs,
-		}),
+	projectID, err := o.getWorkspaceProject(ctx, resource)
+	if err != nil {
+		return nil, client.WrapError(err, "failed to get workspace project")
 	}
 
-	projectResourceId, err := resourceSdk.NewResourceID(projectResourceType, project.ID)
+	teamAccess, err := effectiveWorkspaceAccess(ctx, o.client, orgName, resource.Id.Resource, projectID)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("baton-terraform-cloud: failed to create resource ID for project %v: %w", project.ID, err)
+		return nil, client.WrapError(err, "failed to read workspace team access")
 	}
-	rv := []*v2.Grant{
-		grant.NewGrant(
+
+	rv := make([]*v2.Grant, 0, len(teamAccess))
+	for _, a := range teamAccess {
+		teamResourceId, err := resourceSdk.NewResourceID(teamResourceType, a.id)
+		if err != nil {
+			return nil, fmt.Errorf("baton-terraform-cloud: failed to create resource ID for team %v: %w", a.id, err)
+		}
+		// structpb only converts untyped slices.
+		sources := make([]interface{}, 0, len(a.sources))
+		for _, source := range a.sources {
+			sources = append(sources, source)
+		}
+		metadata, err := structpb.NewStruct(map[string]interface{}{"sources": sources})
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, grant.NewGrant(
 			resource,
-			workspaceMembership,
-			projectResourceId,
-			grantOptions...,
-		),
+			a.level,
+			teamResourceId,
+			grant.WithAnnotation(&v2.GrantExpandable{
+				EntitlementIds: []string{
+					entitlement.NewEntitlementID(&v2.Resource{Id: teamResourceId}, teamMembership),
+				},
+			}),
+			grant.WithAnnotation(&v2.GrantImmutable{
+				SourceId: a.sourceID,
+				Metadata: metadata,
+			}),
+		))
+	}
+	return rv, nil
+}
+
+// stateConsumerGrants returns a page of the workspaces allowed to read the workspace's state.
+// Workspaces reading it through global remote state sharing are not listed, the profile
+// records that flag instead.
+func (o *workspaceBuilder) stateConsumerGrants(ctx context.Context, resource *v2.Resource, page int) ([]*v2.Grant, string, error) {
+	consumers, err := client.Call(ctx, func(ctx context.Context) (*tfe.WorkspaceList, error) {
+		return o.client.Workspaces.ListRemoteStateConsumers(ctx, resource.Id.Resource, &tfe.RemoteStateConsumersListOptions{
+			ListOptions: client.ListOptions(page),
+		})
+	})
+	if err != nil {
+		return nil, "", client.WrapError(err, "failed to list remote state consumers")
+	}
+
+	rv := []*v2.Grant{}
+	for _, consumer := range consumers.Items {
+		principalID, err := resourceSdk.NewResourceID(workspaceResourceType, consumer.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("baton-terraform-cloud: failed to create resource ID for workspace %v: %w", consumer.ID, err)
+		}
+		rv = append(rv, grant.NewGrant(resource, workspaceStateConsumer, principalID))
 	}
 
-	return rv, "", nil, nil
+	var nextPage string
+	if consumers.Pagination != nil && consumers.CurrentPage < consumers.TotalPages {
+		nextPage = stateConsumersPagePrefix + strconv.Itoa(consumers.NextPage)
+	}
+	return rv, nextPage, nil
+}
+
+// checkWorkspaceOrganization fails closed when the workspace's organization is unknown or out of scope.
+func (o *workspaceBuilder) checkWorkspaceOrganization(workspace *v2.Resource) error {
+	if workspace.ParentResourceId == nil {
+		return fmt.Errorf("baton-terraform-cloud: workspace %s has no parent organization", workspace.Id.Resource)
+	}
+	if err := o.client.CheckOrganization(workspace.ParentResourceId.Resource); err != nil {
+		return fmt.Errorf("baton-terraform-cloud: %w", err)
+	}
+	return nil
+}
+
+// isStateConsumer reports whether consumerID is among the remote state consumers of the workspace.
+func (o *workspaceBuilder) isStateConsumer(ctx context.Context, workspaceID, consumerID string) (bool, error) {
+	page := 0
+	for {
+		consumers, err := client.Call(ctx, func(ctx context.Context) (*tfe.WorkspaceList, error) {
+			return o.client.Workspaces.ListRemoteStateConsumers(ctx, workspaceID, &tfe.RemoteStateConsumersListOptions{
+				ListOptions: client.ListOptions(page),
+			})
+		})
+		if err != nil {
+			return false, client.WrapError(err, "failed to list remote state consumers")
+		}
+		for _, consumer := range consumers.Items {
+			if consumer.ID == consumerID {
+				return true, nil
+			}
+		}
+		if consumers.Pagination == nil || consumers.NextPage == 0 {
+			return false, nil
+		}
+		page = consumers.NextPage
+	}
+}
+
+// Grant allows the principal workspace to read the workspace's state.
+func (o *workspaceBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	if entitlement.Slug != workspaceStateConsumer {
+		return nil, fmt.Errorf("baton-terraform-cloud: workspace entitlement %s cannot be provisioned", entitlement.Slug)
+	}
+	if principal.Id.ResourceType != workspaceResourceType.Id {
+		return nil, fmt.Errorf("baton-terraform-cloud: only workspaces can consume workspace state, got %s", principal.Id.ResourceType)
+	}
+	if err := o.checkWorkspaceOrganization(entitlement.Resource); err != nil {
+		return nil, err
+	}
+
+	workspaceID := entitlement.Resource.Id.Resource
+	exists, err := o.isStateConsumer(ctx, workspaceID, principal.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.Workspaces.AddRemoteStateConsumers(ctx, workspaceID, tfe.WorkspaceAddRemoteStateConsumersOptions{
+			Workspaces: []*tfe.Workspace{{ID: principal.Id.Resource}},
+		})
+	})
+	if client.ErrorCode(err) == codes.AlreadyExists {
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	}
+	if err != nil {
+		return nil, client.WrapError(err, "failed to add remote state consumer")
+	}
+	return nil, nil
+}
+
+// Revoke removes the principal workspace from the workspace's remote state consumers. It keeps
+// reading the state when the workspace shares it globally.
+func (o *workspaceBuilder) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	entitlement := grant.Entitlement
+	if entitlement.Slug != workspaceStateConsumer {
+		return nil, fmt.Errorf("baton-terraform-cloud: workspace entitlement %s cannot be provisioned", entitlement.Slug)
+	}
+	if err := o.checkWorkspaceOrganization(entitlement.Resource); err != nil {
+		return nil, err
+	}
+
+	workspaceID := entitlement.Resource.Id.Resource
+	consumerID := grant.Principal.Id.Resource
+	exists, err := o.isStateConsumer(ctx, workspaceID, consumerID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	err = client.CallErr(ctx, func(ctx context.Context) error {
+		return o.client.Workspaces.RemoveRemoteStateConsumers(ctx, workspaceID, tfe.WorkspaceRemoveRemoteStateConsumersOptions{
+			Workspaces: []*tfe.Workspace{{ID: consumerID}},
+		})
+	})
+	if client.ErrorCode(err) == codes.NotFound {
+		// either workspace may have been deleted meanwhile, the grant is only gone if the
+		// consumer is no longer listed.
+		exists, readErr := o.isStateConsumer(ctx, workspaceID, consumerID)
+		if readErr == nil && !exists {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+	}
+	if err != nil {
+		return nil, client.WrapError(err, "failed to remove remote state consumer")
+	}
+
+	if groupTrait, err := resourceSdk.GetGroupTrait(entitlement.Resource); err == nil {
+		if global, ok := groupTrait.GetProfile().AsMap()["globalRemoteState"].(bool); ok && global {
+			ctxzap.Extract(ctx).Warn("baton-terraform-cloud: workspace shares its state globally, the revoked consumer can still read it",
+				zap.String("workspace", workspaceID),
+				zap.String("consumer", consumerID),
+			)
+		}
+	}
+	return nil, nil
 }
 
 func newWorkspaceBuilder(client *client.Client) *workspaceBuilder {
 	return &workspaceBuilder{
-		client:           client,
-		m:                &sync.Mutex{},
-		workspaceProject: make(map[string]*tfe.Project),
+		client: client,
 	}
 }
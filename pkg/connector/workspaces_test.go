@@ -0,0 +1,241 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	resourceSdk "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-terraform-cloud/pkg/client"
+	"github.com/hashicorp/go-tfe"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNewWorkspaceResourceMetadata(t *testing.T) {
+	runAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	workspace := &tfe.Workspace{
+		ID:       "ws-1",
+		Name:     "network",
+		Locked:   true,
+		TagNames: []string{"prod", "network"},
+		EffectiveTagBindings: []*tfe.EffectiveTagBinding{
+			{Key: "env", Value: "prod"},
+			{Key: "team"},
+		},
+		Project:    &tfe.Project{ID: "prj-1"},
+		VCSRepo:    &tfe.VCSRepo{Identifier: "acme/network", Branch: "main"},
+		AgentPool:  &tfe.AgentPool{ID: "apool-1"},
+		CurrentRun: &tfe.Run{ID: "run-1", Status: tfe.RunApplied, CreatedAt: runAt},
+	}
+
+	resource, err := newWorkspaceResource(workspace, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groupTrait, err := resourceSdk.GetGroupTrait(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := groupTrait.GetProfile().AsMap()
+
+	expectedTags := []interface{}{"env:prod", "network", "prod", "team"}
+	if !reflect.DeepEqual(profile["tags"], expectedTags) {
+		t.Errorf("expected tags %v, got %v", expectedTags, profile["tags"])
+	}
+	for key, expected := range map[string]interface{}{
+		"projectId":         "prj-1",
+		"vcsRepoIdentifier": "acme/network",
+		"vcsRepoBranch":     "main",
+		"agentPoolId":       "apool-1",
+		"locked":            true,
+		"lastRunStatus":     "applied",
+		"lastRunAt":         runAt.Format(time.RFC3339),
+	} {
+		if profile[key] != expected {
+			t.Errorf("expected %s to be %v, got %v", key, expected, profile[key])
+		}
+	}
+
+	tagAnnotation := &structpb.Struct{}
+	annos := annotations.Annotations(resource.Annotations)
+	ok, err := annos.Pick(tagAnnotation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected a tag annotation")
+	}
+	if !reflect.DeepEqual(tagAnnotation.AsMap()["tags"], expectedTags) {
+		t.Errorf("expected annotated tags %v, got %v", expectedTags, tagAnnotation.AsMap()["tags"])
+	}
+}
+
+func TestRevokeStateConsumerNotFound(t *testing.T) {
+	revoke := func(t *testing.T, stillListed bool) (annotations.Annotations, error) {
+		listed := 0
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /api/v2/workspaces/ws-1/relationships/remote-state-consumers":
+				listed++
+				if listed > 1 && !stillListed {
+					_, _ = w.Write([]byte(`{"data":[],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":[{"id":"ws-2","type":"workspaces"}],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+			case "DELETE /api/v2/workspaces/ws-1/relationships/remote-state-consumers":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+
+		orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+		workspace := &v2.Resource{Id: &v2.ResourceId{ResourceType: workspaceResourceType.Id, Resource: "ws-1"}, ParentResourceId: orgID}
+		consumer := &v2.Resource{Id: &v2.ResourceId{ResourceType: workspaceResourceType.Id, Resource: "ws-2"}, ParentResourceId: orgID}
+		g := grant.NewGrant(workspace, workspaceStateConsumer, consumer.Id)
+		g.Entitlement.Slug = workspaceStateConsumer
+		return newWorkspaceBuilder(c).Revoke(context.Background(), g)
+	}
+
+	t.Run("removed concurrently", func(t *testing.T) {
+		annos, err := revoke(t, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !annos.Contains(&v2.GrantAlreadyRevoked{}) {
+			t.Errorf("expected GrantAlreadyRevoked, got %v", annos)
+		}
+	})
+
+	t.Run("still a consumer", func(t *testing.T) {
+		if _, err := revoke(t, true); status.Code(err) != codes.NotFound {
+			t.Errorf("expected the NotFound error to be returned, got %v", err)
+		}
+	})
+}
+
+func TestGrantStateConsumer(t *testing.T) {
+	const (
+		listConsumers = "GET /api/v2/workspaces/ws-1/relationships/remote-state-consumers"
+		addConsumer   = "POST /api/v2/workspaces/ws-1/relationships/remote-state-consumers"
+	)
+
+	testCases := []struct {
+		message       string
+		principal     string
+		secondPage    string
+		expected      []string
+		alreadyExists bool
+		expectError   bool
+	}{
+		{"new consumer", "ws-3", "ws-4", []string{listConsumers, listConsumers, addConsumer}, false, false},
+		{"consumer listed on a later page", "ws-3", "ws-3", []string{listConsumers, listConsumers}, true, false},
+		{"user principal", "", "", nil, false, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.message, func(t *testing.T) {
+			var requests []string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				request := r.Method + " " + r.URL.Path
+				requests = append(requests, request)
+				switch request {
+				case listConsumers:
+					if r.URL.Query().Get("page[number]") == "2" {
+						_, _ = w.Write([]byte(`{"data":[{"id":"` + testCase.secondPage + `","type":"workspaces"}],
+"meta":{"pagination":{"current-page":2,"prev-page":1,"total-pages":2}}}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"data":[{"id":"ws-2","type":"workspaces"}],"meta":{"pagination":{"current-page":1,"next-page":2,"total-pages":2}}}`))
+				case addConsumer:
+					body, _ := io.ReadAll(r.Body)
+					if !strings.Contains(string(body), `"`+testCase.principal+`"`) {
+						t.Errorf("expected %s to be added, got %s", testCase.principal, body)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request %s", request)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+			workspace := &v2.Resource{Id: &v2.ResourceId{ResourceType: workspaceResourceType.Id, Resource: "ws-1"}, ParentResourceId: orgID}
+			principal := &v2.Resource{Id: &v2.ResourceId{ResourceType: userResourceType.Id, Resource: "user-1"}, ParentResourceId: orgID}
+			if testCase.principal != "" {
+				principal = &v2.Resource{Id: &v2.ResourceId{ResourceType: workspaceResourceType.Id, Resource: testCase.principal}, ParentResourceId: orgID}
+			}
+			entitlement := &v2.Entitlement{Resource: workspace, Slug: workspaceStateConsumer}
+
+			annos, err := newWorkspaceBuilder(c).Grant(context.Background(), principal, entitlement)
+			if testCase.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", testCase.expectError, err)
+			}
+			if annos.Contains(&v2.GrantAlreadyExists{}) != testCase.alreadyExists {
+				t.Errorf("expected GrantAlreadyExists %t, got %v", testCase.alreadyExists, annos)
+			}
+			if !reflect.DeepEqual(requests, testCase.expected) {
+				t.Errorf("expected the requests %v, got %v", testCase.expected, requests)
+			}
+		})
+	}
+}
+
+func TestListWorkspacesByProject(t *testing.T) {
+	projects := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/projects":
+			projects++
+			if names := r.URL.Query().Get("filter[names]"); names != "network,storage" {
+				t.Errorf("expected the projects to be filtered by name, got %q", names)
+			}
+			_, _ = w.Write([]byte(`{"data":[{"id":"prj-1","type":"projects","attributes":{"name":"network"}},
+{"id":"prj-2","type":"projects","attributes":{"name":"storage"}}],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		case "/api/v2/organizations/acme/workspaces":
+			projectID := r.URL.Query().Get("filter[project][id]")
+			_, _ = w.Write([]byte(`{"data":[{"id":"ws-` + projectID + `","type":"workspaces","attributes":{"name":"` + projectID + `"},
+"relationships":{"project":{"data":{"id":"` + projectID + `","type":"projects"}}}}],"meta":{"pagination":{"current-page":1,"total-pages":1}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, client.WithWorkspaceFilter(&client.WorkspaceFilter{Projects: []string{"network", "storage"}}))
+
+	builder := newWorkspaceBuilder(c)
+	orgID := &v2.ResourceId{ResourceType: organizationResourceType.Id, Resource: "acme"}
+	var listed []string
+	token := &pagination.Token{}
+	for {
+		resources, next, _, err := builder.List(context.Background(), orgID, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, resource := range resources {
+			listed = append(listed, resource.Id.Resource)
+		}
+		if next == "" {
+			break
+		}
+		token = &pagination.Token{Token: next}
+	}
+
+	if !reflect.DeepEqual(listed, []string{"ws-prj-1", "ws-prj-2"}) {
+		t.Errorf("expected the workspaces of both projects, got %v", listed)
+	}
+	if projects != 1 {
+		t.Errorf("expected the project names to be resolved once, got %d requests", projects)
+	}
+}